@@ -56,8 +56,9 @@ func migrateDB(cfg *config.App) {
 
 func startApp(cfg *config.App) {
 	kafkaWriter := kafka.NewWriter(cfg.MessageQueue.Broker, cfg.MessageQueue.TopicRequest)
+	cancelWriter := kafka.NewWriter(cfg.MessageQueue.Broker, cfg.MessageQueue.TopicCancel)
 	kafkaReader := kafka.NewReader(cfg.MessageQueue.Broker, cfg.MessageQueue.TopicReply, cfg.MessageQueue.ScanningGroupID)
-	s := internal.NewServer(cfg, kafkaWriter, kafkaReader)
+	s := internal.NewServer(cfg, kafkaWriter, cancelWriter, kafkaReader)
 	go func() {
 		err := s.Listen()
 		if err != nil {
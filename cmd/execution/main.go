@@ -39,8 +39,9 @@ func main() {
 	infra.ConfigApplication(cfg.EnvConfig)
 
 	kafkaReader := kafka.NewReader(cfg.MessageQueue.Broker, cfg.MessageQueue.TopicRequest, cfg.MessageQueue.WorkerGroupID)
+	cancelReader := kafka.NewReader(cfg.MessageQueue.Broker, cfg.MessageQueue.TopicCancel, cfg.MessageQueue.CancelGroupID)
 	kafkaWriter := kafka.NewWriter(cfg.MessageQueue.Broker, cfg.MessageQueue.TopicReply)
-	exec := execution.New(cfg, kafkaReader, kafkaWriter)
+	exec := execution.New(cfg, kafkaReader, cancelReader, kafkaWriter)
 	defer exec.Stop()
 	log.Printf("Starting execution service")
 	log.Fatal(exec.Run(context.Background()))
@@ -14,6 +14,8 @@ type App struct {
 	MessageQueue   MessageQueueConfig `yaml:"message_queue"`
 	RedisWorker    RedisWorkerConfig  `yaml:"redis_worker"`
 	ScanChecker    ScanCheckerConfig  `yaml:"scan_checker"`
+	Scanner        ScannerConfig      `yaml:"scanner"`
+	Secrets        SecretsConfig      `yaml:"secrets"`
 	HTTPAddr       string             `yaml:"http_addr"`
 	SourceCodesDir string             `yaml:"source_codes_dir"`
 }
@@ -27,7 +29,9 @@ type MessageQueueConfig struct {
 	Broker          string `yaml:"broker"`
 	TopicRequest    string `yaml:"topic_request"`
 	TopicReply      string `yaml:"topic_reply"`
+	TopicCancel     string `yaml:"topic_cancel"`
 	WorkerGroupID   string `yaml:"worker_group_id"`
+	CancelGroupID   string `yaml:"cancel_group_id"`
 	ScanningGroupID string `yaml:"scanning_group_id"`
 }
 
@@ -52,6 +56,37 @@ type ScanCheckerConfig struct {
 	IntervalInMinutes     int `yaml:"interval_in_minutes"`
 }
 
+type ScannerConfig struct {
+	RulesFile   string        `yaml:"rules_file"`
+	Fetcher     string        `yaml:"fetcher"`       // archive or git, scans of the history always use git
+	Stream      bool          `yaml:"stream"`        // scan archives without extracting them, clones still use the disk
+	Concurrency int           `yaml:"concurrency"`   // files of one scan scanned in parallel, one per CPU if unset
+	MaxFileSize int64         `yaml:"max_file_size"` // larger files are skipped, 1 MiB if unset
+	GitLab      GitLabConfig  `yaml:"gitlab"`
+	Archive     ArchiveConfig `yaml:"archive"`
+	Entropy     EntropyConfig `yaml:"entropy"`
+}
+
+type GitLabConfig struct {
+	Hosts string `yaml:"hosts"` // comma separated hosts of self-managed instances, gitlab.com is always served
+}
+
+type SecretsConfig struct {
+	EncryptionKey string `yaml:"encryption_key"` // base64 of 32 bytes, credentials of private repositories are disabled if empty
+}
+
+type ArchiveConfig struct {
+	MaxBytes int64 `yaml:"max_bytes"` // total size of the extracted files, 1 GiB if unset
+	MaxFiles int   `yaml:"max_files"` // number of entries, 100000 if unset
+}
+
+type EntropyConfig struct {
+	Enabled         bool    `yaml:"enabled"`
+	Base64Threshold float64 `yaml:"base64_threshold"`
+	HexThreshold    float64 `yaml:"hex_threshold"`
+	MinLength       int     `yaml:"min_length"`
+}
+
 // Load load config from file and environment variables.
 func Load(filePath string) (*App, error) {
 	if filePath == "" {
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vumanhcuongit/scan/internal/services/api"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) listFindings(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	var req = &api.ListFindingsRequest{}
+	if err := ginCtx.ShouldBindQuery(req); err != nil {
+		log.Warnf("failed to parse request, error: %v", err.Error())
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if req.Size == 0 {
+		req.Size = 20
+	}
+
+	findings, err := h.scanService.ListFindings(ctx, req)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, findings)
+}
+
+func (h *Handler) updateFinding(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+
+	findingIDStr := ginCtx.Param("id")
+	if findingIDStr == "" {
+		log.Warnf("missing finding id")
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	findingID, err := strconv.ParseInt(findingIDStr, 10, 64)
+	if err != nil {
+		log.Warnf("invalid finding id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	var req = &api.UpdateFindingRequest{}
+	if err := ginCtx.ShouldBindJSON(req); err != nil {
+		log.Warnf("failed to parse request, error: %v", err.Error())
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	finding, err := h.scanService.UpdateFinding(ctx, findingID, req)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, finding)
+}
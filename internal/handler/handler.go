@@ -33,10 +33,27 @@ func (h *Handler) Register(router gin.IRouter) {
 	apiGroup.GET("/repositories/:id", h.getRepository)
 	apiGroup.PATCH("/repositories/:id", h.updateRepository)
 	apiGroup.DELETE("/repositories/:id", h.deleteRepository)
+	apiGroup.PUT("/repositories/:id/credential", h.setRepositoryCredential)
+	apiGroup.GET("/repositories/:id/credential", h.getRepositoryCredential)
+	apiGroup.DELETE("/repositories/:id/credential", h.deleteRepositoryCredential)
+	apiGroup.PUT("/repositories/:id/baseline", h.setRepositoryBaseline)
+	apiGroup.GET("/repositories/:id/baseline", h.getRepositoryBaseline)
+	apiGroup.DELETE("/repositories/:id/baseline", h.deleteRepositoryBaseline)
 
 	// scans
 	apiGroup.POST("/scans", h.createScan)
 	apiGroup.GET("/scans", h.listScans)
+	apiGroup.GET("/scans/:id", h.getScan)
+	apiGroup.GET("/scans/:id/report", h.getScanReport)
+	apiGroup.GET("/scans/:id/findings/suppressed", h.listSuppressedFindings)
+	apiGroup.GET("/scans/:id/diff", h.getScanDiff)
+	apiGroup.POST("/scans/:id/cancel", h.cancelScan)
+	apiGroup.POST("/scans/:id/retry", h.retryScan)
+	apiGroup.POST("/scans/:id/rerun", h.rerunScan)
+
+	// findings
+	apiGroup.GET("/findings", h.listFindings)
+	apiGroup.PATCH("/findings/:id", h.updateFinding)
 }
 
 func (h *Handler) SetScanService(scanService api.IScanService) {
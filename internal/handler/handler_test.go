@@ -17,6 +17,9 @@ import (
 	"github.com/vumanhcuongit/scan/internal/config"
 	"github.com/vumanhcuongit/scan/internal/services/api"
 	"github.com/vumanhcuongit/scan/pkg/models"
+	"github.com/vumanhcuongit/scan/pkg/sarif"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type handlerSuite struct {
@@ -289,6 +292,94 @@ func (s *handlerSuite) TestDeleteRepositoryWithFailedDeletion() {
 	s.Equal(500, respBody.Error.Code)
 }
 
+func (s *handlerSuite) TestSetRepositoryCredential() {
+	request := &api.SetRepositoryCredentialRequest{
+		Kind:   models.CredentialKindToken,
+		Secret: "glpat-secret",
+	}
+	bodyData, _ := json.Marshal(request)
+	credential := &models.RepositoryCredential{
+		ID:              1,
+		RepositoryID:    2,
+		Kind:            models.CredentialKindToken,
+		EncryptedSecret: "c2VhbGVk",
+	}
+	s.scanService.EXPECT().SetRepositoryCredential(gomock.Any(), credential.RepositoryID, request).Return(credential, nil)
+
+	resp := performHandlerRequest(s.router, "PUT",
+		fmt.Sprintf("/api/repositories/%d/credential", credential.RepositoryID), bytes.NewReader(bodyData))
+	s.Equal(200, resp.Code)
+	s.NotContains(resp.Body.String(), credential.EncryptedSecret)
+	s.NotContains(resp.Body.String(), "secret")
+	var respBody struct {
+		Data struct {
+			RepositoryID int64  `json:"repository_id"`
+			Kind         string `json:"kind"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Equal(credential.RepositoryID, respBody.Data.RepositoryID)
+	s.Equal(models.CredentialKindToken, respBody.Data.Kind)
+}
+
+func (s *handlerSuite) TestSetRepositoryCredentialWithInvalidParams() {
+	resp := performHandlerRequest(s.router, "PUT", "/api/repositories/2/credential",
+		bytes.NewReader([]byte(`{"kind": "token"}`)))
+	s.Equal(400, resp.Code)
+}
+
+func (s *handlerSuite) TestGetRepositoryCredentialWithoutCredential() {
+	s.scanService.EXPECT().GetRepositoryCredential(gomock.Any(), int64(2)).
+		Return(nil, status.Error(codes.NotFound, "repository 2 has no credential"))
+
+	resp := performHandlerRequest(s.router, "GET", "/api/repositories/2/credential", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Equal(404, respBody.Error.Code)
+}
+
+func (s *handlerSuite) TestDeleteRepositoryCredential() {
+	s.scanService.EXPECT().DeleteRepositoryCredential(gomock.Any(), int64(2)).Return(nil)
+
+	resp := performHandlerRequest(s.router, "DELETE", "/api/repositories/2/credential", nil)
+	s.Equal(204, resp.Code)
+}
+
+func (s *handlerSuite) TestSetRepositoryBaseline() {
+	scanID := int64(4)
+	request := &api.SetRepositoryBaselineRequest{ScanID: &scanID}
+	bodyData, _ := json.Marshal(request)
+	baseline := &models.RepositoryBaseline{ID: 1, RepositoryID: 2, Fingerprints: []byte(`["3f1c8e0b"]`)}
+	s.scanService.EXPECT().SetRepositoryBaseline(gomock.Any(), baseline.RepositoryID, request).Return(baseline, nil)
+
+	resp := performHandlerRequest(s.router, "PUT", "/api/repositories/2/baseline", bytes.NewReader(bodyData))
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data struct {
+			RepositoryID int64    `json:"repository_id"`
+			Fingerprints []string `json:"fingerprints"`
+		} `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Equal(baseline.RepositoryID, respBody.Data.RepositoryID)
+	s.Equal([]string{"3f1c8e0b"}, respBody.Data.Fingerprints)
+}
+
+func (s *handlerSuite) TestDeleteRepositoryBaseline() {
+	s.scanService.EXPECT().DeleteRepositoryBaseline(gomock.Any(), int64(2)).Return(nil)
+
+	resp := performHandlerRequest(s.router, "DELETE", "/api/repositories/2/baseline", nil)
+	s.Equal(204, resp.Code)
+}
+
 func (s *handlerSuite) TestCreateScan() {
 	request := &api.TriggerScanRequest{
 		RepositoryID: 1,
@@ -393,6 +484,283 @@ func (s *handlerSuite) TestListScansWithError() {
 	s.Require().Equal("failed to list scans", respBody.Error.Message)
 }
 
+func (s *handlerSuite) TestGetScan() {
+	detail := map[string]json.RawMessage{"id": json.RawMessage(`1`), "status": json.RawMessage(`"Success"`)}
+	s.scanService.EXPECT().GetScan(gomock.Any(), int64(1), &api.GetScanRequest{Fields: "id,status"}).Return(detail, nil)
+
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/1?fields=id,status", nil)
+	s.Equal(200, resp.Code)
+	s.JSONEq(`{"data":{"id":1,"status":"Success"}}`, resp.Body.String())
+}
+
+func (s *handlerSuite) TestGetScanWithInvalidID() {
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/abc", nil)
+	s.Equal(400, resp.Code)
+}
+
+func (s *handlerSuite) TestGetScanReport() {
+	request := &api.GetScanReportRequest{Format: api.ReportFormatSARIF}
+	report := sarif.NewLog(&models.Scan{ID: 1}, []models.Finding{{RuleID: "G101"}})
+	s.scanService.EXPECT().GetScanReport(gomock.Any(), int64(1), request).Return(report, nil)
+
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/1/report", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(sarif.Version, respBody.Version)
+	s.Require().Equal(1, len(respBody.Runs))
+	s.Require().Equal("G101", respBody.Runs[0].Results[0].RuleID)
+}
+
+func (s *handlerSuite) TestGetScanReportWithUnsupportedFormat() {
+	request := &api.GetScanReportRequest{Format: "csv"}
+	s.scanService.EXPECT().GetScanReport(gomock.Any(), int64(1), request).
+		Return(nil, status.Error(codes.InvalidArgument, "unsupported report format: csv"))
+
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/1/report?format=csv", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data  interface{} `json:"data"`
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Nil(respBody.Data)
+	s.Require().Equal(400, respBody.Error.Code)
+}
+
+func (s *handlerSuite) TestGetScanReportWithNotFoundScan() {
+	request := &api.GetScanReportRequest{Format: api.ReportFormatSARIF}
+	s.scanService.EXPECT().GetScanReport(gomock.Any(), int64(1), request).
+		Return(nil, status.Error(codes.NotFound, "scan 1 not found"))
+
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/1/report", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(404, respBody.Error.Code)
+}
+
+func (s *handlerSuite) TestGetScanReportOfUnfinishedScan() {
+	request := &api.GetScanReportRequest{Format: api.ReportFormatSARIF}
+	s.scanService.EXPECT().GetScanReport(gomock.Any(), int64(1), request).
+		Return(nil, status.Error(codes.FailedPrecondition, "scan 1 has not succeeded, its status is In Progress"))
+
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/1/report", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(400, respBody.Error.Code)
+}
+
+func (s *handlerSuite) TestGetScanReportWithInvalidID() {
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/abc/report", nil)
+	s.Equal(400, resp.Code)
+}
+
+func (s *handlerSuite) TestListSuppressedFindings() {
+	findings := []models.Finding{{RuleID: "G101", Suppressed: true, Justification: "test fixture"}}
+	s.scanService.EXPECT().ListSuppressedFindings(gomock.Any(), int64(1)).Return(findings, nil)
+
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/1/findings/suppressed", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data []models.Finding `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(findings, respBody.Data)
+}
+
+func (s *handlerSuite) TestListSuppressedFindingsWithInvalidID() {
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/abc/findings/suppressed", nil)
+	s.Equal(400, resp.Code)
+}
+
+func (s *handlerSuite) TestGetScanDiff() {
+	base := int64(1)
+	diff := &models.ScanDiff{
+		ScanID:     2,
+		BaseScanID: &base,
+		New:        []models.Finding{{RuleID: "G101", Fingerprint: "added"}},
+		Fixed:      []models.Finding{},
+		Unchanged:  []models.Finding{},
+	}
+	s.scanService.EXPECT().GetScanDiff(gomock.Any(), int64(2), &api.GetScanDiffRequest{Base: &base}).Return(diff, nil)
+
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/2/diff?base=1", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data models.ScanDiff `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(*diff, respBody.Data)
+}
+
+func (s *handlerSuite) TestGetScanDiffWithInvalidBase() {
+	resp := performHandlerRequest(s.router, "GET", "/api/scans/2/diff?base=abc", nil)
+	s.Equal(400, resp.Code)
+}
+
+func (s *handlerSuite) TestCancelScan() {
+	scan := &models.Scan{ID: 2, Status: models.ScanStatusCancelled}
+	s.scanService.EXPECT().CancelScan(gomock.Any(), int64(2)).Return(scan, nil)
+
+	resp := performHandlerRequest(s.router, "POST", "/api/scans/2/cancel", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data models.Scan `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(models.ScanStatusCancelled, respBody.Data.Status)
+}
+
+func (s *handlerSuite) TestCancelFinishedScan() {
+	s.scanService.EXPECT().CancelScan(gomock.Any(), int64(2)).
+		Return(nil, status.Error(codes.FailedPrecondition, "scan 2 has already finished"))
+
+	resp := performHandlerRequest(s.router, "POST", "/api/scans/2/cancel", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Equal(400, respBody.Error.Code)
+}
+
+func (s *handlerSuite) TestRetryScan() {
+	parentScanID := int64(2)
+	scan := &models.Scan{ID: 3, ParentScanID: &parentScanID, Status: models.ScanStatusQueued}
+	s.scanService.EXPECT().RetryScan(gomock.Any(), parentScanID).Return(scan, nil)
+
+	resp := performHandlerRequest(s.router, "POST", "/api/scans/2/retry", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data models.Scan `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(int64(3), respBody.Data.ID)
+	s.Require().Equal(parentScanID, *respBody.Data.ParentScanID)
+}
+
+func (s *handlerSuite) TestRerunScan() {
+	parentScanID := int64(2)
+	scan := &models.Scan{ID: 3, ParentScanID: &parentScanID, Status: models.ScanStatusQueued}
+	s.scanService.EXPECT().RerunScan(gomock.Any(), parentScanID).Return(scan, nil)
+
+	resp := performHandlerRequest(s.router, "POST", "/api/scans/2/rerun", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data models.Scan `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(int64(3), respBody.Data.ID)
+	s.Require().Equal(parentScanID, *respBody.Data.ParentScanID)
+}
+
+func (s *handlerSuite) TestRerunUnfinishedScan() {
+	s.scanService.EXPECT().RerunScan(gomock.Any(), int64(2)).
+		Return(nil, status.Error(codes.FailedPrecondition, "scan 2 cannot be rerun before it finishes, its status is Queued"))
+
+	resp := performHandlerRequest(s.router, "POST", "/api/scans/2/rerun", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Equal(400, respBody.Error.Code)
+}
+
+func (s *handlerSuite) TestRetryScanWithInvalidID() {
+	resp := performHandlerRequest(s.router, "POST", "/api/scans/abc/retry", nil)
+	s.Equal(400, resp.Code)
+}
+
+func (s *handlerSuite) TestListFindings() {
+	repositoryID := int64(2)
+	severity := "HIGH"
+	path := "config/"
+	request := &api.ListFindingsRequest{RepositoryID: &repositoryID, Severity: &severity, Path: &path, Size: 20, Page: 1}
+	findings := []*models.FindingRecord{{ID: 1, RepositoryID: repositoryID, Severity: severity, Path: "config/app.yaml"}}
+	s.scanService.EXPECT().ListFindings(gomock.Any(), request).Return(findings, nil)
+
+	resp := performHandlerRequest(s.router, "GET", "/api/findings?repository_id=2&severity=HIGH&path=config/&page=1", nil)
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data []models.FindingRecord `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Len(respBody.Data, 1)
+	s.Require().Equal("config/app.yaml", respBody.Data[0].Path)
+}
+
+func (s *handlerSuite) TestUpdateFinding() {
+	request := &api.UpdateFindingRequest{
+		State:   models.FindingStateFalsePositive,
+		Comment: "example key from the docs",
+		Actor:   "alice@example.com",
+	}
+	bodyData, _ := json.Marshal(request)
+	finding := &models.FindingRecord{
+		ID:      1,
+		State:   request.State,
+		Comment: request.Comment,
+		Actor:   request.Actor,
+	}
+	s.scanService.EXPECT().UpdateFinding(gomock.Any(), finding.ID, request).Return(finding, nil)
+
+	resp := performHandlerRequest(s.router, "PATCH", "/api/findings/1", bytes.NewReader(bodyData))
+	s.Equal(200, resp.Code)
+	var respBody struct {
+		Data models.FindingRecord `json:"data"`
+	}
+	err := json.Unmarshal(resp.Body.Bytes(), &respBody)
+	s.NoError(err)
+	s.Require().Equal(*finding, respBody.Data)
+}
+
+func (s *handlerSuite) TestUpdateFindingWithInvalidParams() {
+	resp := performHandlerRequest(s.router, "PATCH", "/api/findings/1",
+		bytes.NewReader([]byte(`{"state": "acknowledged"}`)))
+	s.Equal(400, resp.Code)
+
+	resp = performHandlerRequest(s.router, "PATCH", "/api/findings/abc",
+		bytes.NewReader([]byte(`{"state": "acknowledged", "actor": "alice@example.com"}`)))
+	s.Equal(400, resp.Code)
+}
+
 func performHandlerRequest(h http.Handler, method string, path string, body io.Reader) *httptest.ResponseRecorder {
 	r, _ := http.NewRequest(method, path, body)
 	r.Header.Add("Content-Type", "application/json")
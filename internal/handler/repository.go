@@ -133,3 +133,173 @@ func (h *Handler) deleteRepository(ginCtx *gin.Context) {
 
 	h.ReturnNoConent(ginCtx)
 }
+
+func (h *Handler) setRepositoryCredential(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+
+	repositoryIDStr := ginCtx.Param("id")
+	if repositoryIDStr == "" {
+		log.Warnf("missing repository id")
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	repositoryID, err := strconv.ParseInt(repositoryIDStr, 10, 64)
+	if err != nil {
+		log.Warnf("invalid repository id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	var req = &api.SetRepositoryCredentialRequest{}
+	if err := ginCtx.ShouldBindJSON(req); err != nil {
+		log.Warnf("failed to parse request, error: %v", err.Error())
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	credential, err := h.scanService.SetRepositoryCredential(ctx, repositoryID, req)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, credential)
+}
+
+func (h *Handler) getRepositoryCredential(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	repositoryIDStr := ginCtx.Param("id")
+	if repositoryIDStr == "" {
+		log.Warnf("missing repository id")
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	repositoryID, err := strconv.ParseInt(repositoryIDStr, 10, 64)
+	if err != nil {
+		log.Warnf("invalid repository id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	credential, err := h.scanService.GetRepositoryCredential(ctx, repositoryID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, credential)
+}
+
+func (h *Handler) deleteRepositoryCredential(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	repositoryIDStr := ginCtx.Param("id")
+	if repositoryIDStr == "" {
+		log.Warnf("missing repository id")
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	repositoryID, err := strconv.ParseInt(repositoryIDStr, 10, 64)
+	if err != nil {
+		log.Warnf("invalid repository id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	err = h.scanService.DeleteRepositoryCredential(ctx, repositoryID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnNoConent(ginCtx)
+}
+
+func (h *Handler) setRepositoryBaseline(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+
+	repositoryIDStr := ginCtx.Param("id")
+	if repositoryIDStr == "" {
+		log.Warnf("missing repository id")
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	repositoryID, err := strconv.ParseInt(repositoryIDStr, 10, 64)
+	if err != nil {
+		log.Warnf("invalid repository id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	var req = &api.SetRepositoryBaselineRequest{}
+	if err := ginCtx.ShouldBindJSON(req); err != nil {
+		log.Warnf("failed to parse request, error: %v", err.Error())
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	baseline, err := h.scanService.SetRepositoryBaseline(ctx, repositoryID, req)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, baseline)
+}
+
+func (h *Handler) getRepositoryBaseline(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	repositoryIDStr := ginCtx.Param("id")
+	if repositoryIDStr == "" {
+		log.Warnf("missing repository id")
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	repositoryID, err := strconv.ParseInt(repositoryIDStr, 10, 64)
+	if err != nil {
+		log.Warnf("invalid repository id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	baseline, err := h.scanService.GetRepositoryBaseline(ctx, repositoryID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, baseline)
+}
+
+func (h *Handler) deleteRepositoryBaseline(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	repositoryIDStr := ginCtx.Param("id")
+	if repositoryIDStr == "" {
+		log.Warnf("missing repository id")
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	repositoryID, err := strconv.ParseInt(repositoryIDStr, 10, 64)
+	if err != nil {
+		log.Warnf("invalid repository id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	err = h.scanService.DeleteRepositoryBaseline(ctx, repositoryID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnNoConent(ginCtx)
+}
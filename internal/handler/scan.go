@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vumanhcuongit/scan/internal/services/api"
@@ -47,3 +48,162 @@ func (h *Handler) listScans(ginCtx *gin.Context) {
 
 	h.ReturnData(ginCtx, scans)
 }
+
+func (h *Handler) getScan(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	scanID, err := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if err != nil {
+		log.Warnf("invalid scan id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	var req = &api.GetScanRequest{}
+	if err := ginCtx.ShouldBindQuery(req); err != nil {
+		log.Warnf("failed to parse request, error: %v", err.Error())
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	scan, err := h.scanService.GetScan(ctx, scanID, req)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, scan)
+}
+
+// getScanReport responds with the report document itself, not wrapped in data,
+// so that it can be uploaded as is to tools consuming the format.
+func (h *Handler) getScanReport(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	scanID, err := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if err != nil {
+		log.Warnf("invalid scan id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	var req = &api.GetScanReportRequest{}
+	if err := ginCtx.ShouldBindQuery(req); err != nil {
+		log.Warnf("failed to parse request, error: %v", err.Error())
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if req.Format == "" {
+		req.Format = api.ReportFormatSARIF
+	}
+
+	report, err := h.scanService.GetScanReport(ctx, scanID, req)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	ginCtx.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) listSuppressedFindings(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	scanID, err := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if err != nil {
+		log.Warnf("invalid scan id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	findings, err := h.scanService.ListSuppressedFindings(ctx, scanID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, findings)
+}
+
+func (h *Handler) getScanDiff(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	scanID, err := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if err != nil {
+		log.Warnf("invalid scan id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	var req = &api.GetScanDiffRequest{}
+	if err := ginCtx.ShouldBindQuery(req); err != nil {
+		log.Warnf("failed to parse request, error: %v", err.Error())
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	diff, err := h.scanService.GetScanDiff(ctx, scanID, req)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, diff)
+}
+
+func (h *Handler) cancelScan(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	scanID, err := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if err != nil {
+		log.Warnf("invalid scan id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	scan, err := h.scanService.CancelScan(ctx, scanID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, scan)
+}
+
+func (h *Handler) retryScan(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	scanID, err := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if err != nil {
+		log.Warnf("invalid scan id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	scan, err := h.scanService.RetryScan(ctx, scanID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, scan)
+}
+
+func (h *Handler) rerunScan(ginCtx *gin.Context) {
+	ctx := ginCtx.Request.Context()
+	log := zap.S()
+	scanID, err := strconv.ParseInt(ginCtx.Param("id"), 10, 64)
+	if err != nil {
+		log.Warnf("invalid scan id, err: %+v", err)
+		ginCtx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	scan, err := h.scanService.RerunScan(ctx, scanID)
+	if err != nil {
+		h.ReturnError(ginCtx, err)
+		return
+	}
+
+	h.ReturnData(ginCtx, scan)
+}
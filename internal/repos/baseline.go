@@ -0,0 +1,49 @@
+package repos
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+type RepositoryBaselineSQLRepo struct {
+	db *gorm.DB
+}
+
+// NewRepositoryBaselineSQLRepo returns a new IRepositoryBaselineRepo
+func NewRepositoryBaselineSQLRepo(db *gorm.DB) IRepositoryBaselineRepo {
+	return &RepositoryBaselineSQLRepo{
+		db: db,
+	}
+}
+
+func (r *RepositoryBaselineSQLRepo) dbWithContext(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx)
+}
+
+func (r *RepositoryBaselineSQLRepo) GetByRepositoryID(ctx context.Context, repositoryID int64) (*models.RepositoryBaseline, error) {
+	record := &models.RepositoryBaseline{}
+	err := r.dbWithContext(ctx).Where("repository_id = ?", repositoryID).First(record).Error
+	return record, err
+}
+
+// Upsert creates the baseline of a repository or replaces the existing one.
+func (r *RepositoryBaselineSQLRepo) Upsert(ctx context.Context, record *models.RepositoryBaseline) (*models.RepositoryBaseline, error) {
+	err := r.dbWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repository_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fingerprints", "updated_at"}),
+	}).Create(record).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// the record keeps the id and the creation time of the replaced baseline
+	return r.GetByRepositoryID(ctx, record.RepositoryID)
+}
+
+func (r *RepositoryBaselineSQLRepo) Delete(ctx context.Context, record *models.RepositoryBaseline) error {
+	return r.dbWithContext(ctx).Delete(record).Error
+}
@@ -0,0 +1,49 @@
+package repos
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+type RepositoryCredentialSQLRepo struct {
+	db *gorm.DB
+}
+
+// NewRepositoryCredentialSQLRepo returns a new IRepositoryCredentialRepo
+func NewRepositoryCredentialSQLRepo(db *gorm.DB) IRepositoryCredentialRepo {
+	return &RepositoryCredentialSQLRepo{
+		db: db,
+	}
+}
+
+func (r *RepositoryCredentialSQLRepo) dbWithContext(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx)
+}
+
+func (r *RepositoryCredentialSQLRepo) GetByRepositoryID(ctx context.Context, repositoryID int64) (*models.RepositoryCredential, error) {
+	record := &models.RepositoryCredential{}
+	err := r.dbWithContext(ctx).Where("repository_id = ?", repositoryID).First(record).Error
+	return record, err
+}
+
+// Upsert creates the credential of a repository or replaces the existing one.
+func (r *RepositoryCredentialSQLRepo) Upsert(ctx context.Context, record *models.RepositoryCredential) (*models.RepositoryCredential, error) {
+	err := r.dbWithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repository_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "username", "encrypted_secret", "updated_at"}),
+	}).Create(record).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// the record keeps the id and the creation time of the replaced credential
+	return r.GetByRepositoryID(ctx, record.RepositoryID)
+}
+
+func (r *RepositoryCredentialSQLRepo) Delete(ctx context.Context, record *models.RepositoryCredential) error {
+	return r.dbWithContext(ctx).Delete(record).Error
+}
@@ -0,0 +1,140 @@
+package repos
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+type FindingSQLRepo struct {
+	db *gorm.DB
+}
+
+// NewFindingSQLRepo returns a new IFindingRepo
+func NewFindingSQLRepo(db *gorm.DB) IFindingRepo {
+	return &FindingSQLRepo{
+		db: db,
+	}
+}
+
+func (r *FindingSQLRepo) dbWithContext(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx)
+}
+
+func (r *FindingSQLRepo) GetByID(ctx context.Context, id int64) (*models.FindingRecord, error) {
+	record := &models.FindingRecord{}
+	err := r.dbWithContext(ctx).Where("id = ?", id).First(record).Error
+	return record, err
+}
+
+// CreateIgnoringDuplicates creates the findings a repository does not track yet, the
+// ones with a known fingerprint are left as they are.
+func (r *FindingSQLRepo) CreateIgnoringDuplicates(ctx context.Context, records []*models.FindingRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return r.dbWithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(records).
+		Error
+}
+
+func (r *FindingSQLRepo) ListByFingerprints(
+	ctx context.Context,
+	repositoryID int64,
+	fingerprints []string,
+) ([]*models.FindingRecord, error) {
+	var records []*models.FindingRecord
+	if len(fingerprints) == 0 {
+		return records, nil
+	}
+
+	err := r.dbWithContext(ctx).
+		Where("repository_id = ? AND fingerprint IN (?)", repositoryID, fingerprints).
+		Find(&records).Error
+	return records, err
+}
+
+// MarkSeen records that the scan reported the findings again. A resolved finding that
+// comes back is open again, the other triage decisions carry over.
+func (r *FindingSQLRepo) MarkSeen(ctx context.Context, scanID int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.dbWithContext(ctx).
+		Model(models.FindingRecord{}).
+		Where("id IN (?)", ids).
+		Updates(map[string]interface{}{
+			"last_scan_id": gorm.Expr("GREATEST(last_scan_id, ?)", scanID),
+			"state": gorm.Expr("CASE WHEN state = ? THEN ? ELSE state END",
+				models.FindingStateResolved, models.FindingStateOpen),
+		}).Error
+}
+
+func (r *FindingSQLRepo) UpdateWithMap(
+	ctx context.Context,
+	record *models.FindingRecord,
+	params map[string]interface{},
+) error {
+	return r.dbWithContext(ctx).
+		Model(record).
+		Updates(params).
+		Error
+}
+
+func (r *FindingSQLRepo) List(
+	ctx context.Context,
+	size int,
+	page int,
+	filter *models.FindingFilter,
+) ([]*models.FindingRecord, error) {
+	var records []*models.FindingRecord
+	query := r.buildQueryFromFilter(ctx, filter)
+	offset := (page - 1) * size
+	err := query.Order("id DESC").Limit(size).Offset(offset).Find(&records).Error
+	return records, err
+}
+
+func (r *FindingSQLRepo) buildQueryFromFilter(
+	ctx context.Context,
+	filter *models.FindingFilter,
+) *gorm.DB {
+	query := r.dbWithContext(ctx)
+
+	if filter == nil {
+		return query
+	}
+
+	if filter.RepositoryID != nil {
+		query = query.Where("repository_id = ?", filter.RepositoryID)
+	}
+
+	if filter.RuleID != nil {
+		query = query.Where("rule_id = ?", filter.RuleID)
+	}
+
+	if filter.Severity != nil {
+		query = query.Where("severity = ?", filter.Severity)
+	}
+
+	if filter.PathPrefix != nil {
+		query = query.Where("path LIKE ?", escapeLike(*filter.PathPrefix)+"%")
+	}
+
+	if filter.State != nil {
+		query = query.Where("state = ?", filter.State)
+	}
+
+	return query
+}
+
+// escapeLike makes the wildcards of a LIKE pattern match themselves.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
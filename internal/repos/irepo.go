@@ -12,6 +12,9 @@ type IRepo interface {
 	WithTransaction(ctx context.Context, fn func(IRepo) error) (err error)
 	Repository() IRepositoryRepo
 	Scan() IScanRepo
+	RepositoryCredential() IRepositoryCredentialRepo
+	RepositoryBaseline() IRepositoryBaselineRepo
+	Finding() IFindingRepo
 }
 
 type IRepositoryRepo interface {
@@ -31,6 +34,18 @@ type IRepositoryRepo interface {
 	) ([]*models.Repository, error)
 }
 
+type IRepositoryCredentialRepo interface {
+	GetByRepositoryID(ctx context.Context, repositoryID int64) (*models.RepositoryCredential, error)
+	Upsert(ctx context.Context, record *models.RepositoryCredential) (*models.RepositoryCredential, error)
+	Delete(ctx context.Context, record *models.RepositoryCredential) error
+}
+
+type IRepositoryBaselineRepo interface {
+	GetByRepositoryID(ctx context.Context, repositoryID int64) (*models.RepositoryBaseline, error)
+	Upsert(ctx context.Context, record *models.RepositoryBaseline) (*models.RepositoryBaseline, error)
+	Delete(ctx context.Context, record *models.RepositoryBaseline) error
+}
+
 type IScanRepo interface {
 	Create(ctx context.Context, record *models.Scan) (*models.Scan, error)
 	GetByID(ctx context.Context, id int64) (*models.Scan, error)
@@ -39,6 +54,17 @@ type IScanRepo interface {
 		record *models.Scan,
 		params map[string]interface{},
 	) error
+	UpdateWithMapFromStatuses(
+		ctx context.Context,
+		record *models.Scan,
+		statuses []string,
+		params map[string]interface{},
+	) (bool, error)
+	UpdateStaleFailureWithMap(
+		ctx context.Context,
+		record *models.Scan,
+		params map[string]interface{},
+	) (bool, error)
 	Delete(ctx context.Context, record *models.Scan) error
 	List(
 		ctx context.Context,
@@ -48,3 +74,25 @@ type IScanRepo interface {
 	) ([]*models.Scan, error)
 	MarkStaleScansAsFailure(ctx context.Context, maxMinutes int) error
 }
+
+type IFindingRepo interface {
+	GetByID(ctx context.Context, id int64) (*models.FindingRecord, error)
+	CreateIgnoringDuplicates(ctx context.Context, records []*models.FindingRecord) error
+	ListByFingerprints(
+		ctx context.Context,
+		repositoryID int64,
+		fingerprints []string,
+	) ([]*models.FindingRecord, error)
+	MarkSeen(ctx context.Context, scanID int64, ids []int64) error
+	UpdateWithMap(
+		ctx context.Context,
+		record *models.FindingRecord,
+		params map[string]interface{},
+	) error
+	List(
+		ctx context.Context,
+		size int,
+		page int,
+		filter *models.FindingFilter,
+	) ([]*models.FindingRecord, error)
+}
@@ -35,6 +35,20 @@ func (m *MockIRepo) EXPECT() *MockIRepoMockRecorder {
 	return m.recorder
 }
 
+// Finding mocks base method.
+func (m *MockIRepo) Finding() IFindingRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finding")
+	ret0, _ := ret[0].(IFindingRepo)
+	return ret0
+}
+
+// Finding indicates an expected call of Finding.
+func (mr *MockIRepoMockRecorder) Finding() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finding", reflect.TypeOf((*MockIRepo)(nil).Finding))
+}
+
 // Repository mocks base method.
 func (m *MockIRepo) Repository() IRepositoryRepo {
 	m.ctrl.T.Helper()
@@ -49,6 +63,34 @@ func (mr *MockIRepoMockRecorder) Repository() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repository", reflect.TypeOf((*MockIRepo)(nil).Repository))
 }
 
+// RepositoryBaseline mocks base method.
+func (m *MockIRepo) RepositoryBaseline() IRepositoryBaselineRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepositoryBaseline")
+	ret0, _ := ret[0].(IRepositoryBaselineRepo)
+	return ret0
+}
+
+// RepositoryBaseline indicates an expected call of RepositoryBaseline.
+func (mr *MockIRepoMockRecorder) RepositoryBaseline() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepositoryBaseline", reflect.TypeOf((*MockIRepo)(nil).RepositoryBaseline))
+}
+
+// RepositoryCredential mocks base method.
+func (m *MockIRepo) RepositoryCredential() IRepositoryCredentialRepo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepositoryCredential")
+	ret0, _ := ret[0].(IRepositoryCredentialRepo)
+	return ret0
+}
+
+// RepositoryCredential indicates an expected call of RepositoryCredential.
+func (mr *MockIRepoMockRecorder) RepositoryCredential() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepositoryCredential", reflect.TypeOf((*MockIRepo)(nil).RepositoryCredential))
+}
+
 // Scan mocks base method.
 func (m *MockIRepo) Scan() IScanRepo {
 	m.ctrl.T.Helper()
@@ -185,6 +227,140 @@ func (mr *MockIRepositoryRepoMockRecorder) UpdateWithMap(ctx, record, params int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithMap", reflect.TypeOf((*MockIRepositoryRepo)(nil).UpdateWithMap), ctx, record, params)
 }
 
+// MockIRepositoryCredentialRepo is a mock of IRepositoryCredentialRepo interface.
+type MockIRepositoryCredentialRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockIRepositoryCredentialRepoMockRecorder
+}
+
+// MockIRepositoryCredentialRepoMockRecorder is the mock recorder for MockIRepositoryCredentialRepo.
+type MockIRepositoryCredentialRepoMockRecorder struct {
+	mock *MockIRepositoryCredentialRepo
+}
+
+// NewMockIRepositoryCredentialRepo creates a new mock instance.
+func NewMockIRepositoryCredentialRepo(ctrl *gomock.Controller) *MockIRepositoryCredentialRepo {
+	mock := &MockIRepositoryCredentialRepo{ctrl: ctrl}
+	mock.recorder = &MockIRepositoryCredentialRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIRepositoryCredentialRepo) EXPECT() *MockIRepositoryCredentialRepoMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockIRepositoryCredentialRepo) Delete(ctx context.Context, record *models.RepositoryCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIRepositoryCredentialRepoMockRecorder) Delete(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIRepositoryCredentialRepo)(nil).Delete), ctx, record)
+}
+
+// GetByRepositoryID mocks base method.
+func (m *MockIRepositoryCredentialRepo) GetByRepositoryID(ctx context.Context, repositoryID int64) (*models.RepositoryCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRepositoryID", ctx, repositoryID)
+	ret0, _ := ret[0].(*models.RepositoryCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRepositoryID indicates an expected call of GetByRepositoryID.
+func (mr *MockIRepositoryCredentialRepoMockRecorder) GetByRepositoryID(ctx, repositoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRepositoryID", reflect.TypeOf((*MockIRepositoryCredentialRepo)(nil).GetByRepositoryID), ctx, repositoryID)
+}
+
+// Upsert mocks base method.
+func (m *MockIRepositoryCredentialRepo) Upsert(ctx context.Context, record *models.RepositoryCredential) (*models.RepositoryCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, record)
+	ret0, _ := ret[0].(*models.RepositoryCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockIRepositoryCredentialRepoMockRecorder) Upsert(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockIRepositoryCredentialRepo)(nil).Upsert), ctx, record)
+}
+
+// MockIRepositoryBaselineRepo is a mock of IRepositoryBaselineRepo interface.
+type MockIRepositoryBaselineRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockIRepositoryBaselineRepoMockRecorder
+}
+
+// MockIRepositoryBaselineRepoMockRecorder is the mock recorder for MockIRepositoryBaselineRepo.
+type MockIRepositoryBaselineRepoMockRecorder struct {
+	mock *MockIRepositoryBaselineRepo
+}
+
+// NewMockIRepositoryBaselineRepo creates a new mock instance.
+func NewMockIRepositoryBaselineRepo(ctrl *gomock.Controller) *MockIRepositoryBaselineRepo {
+	mock := &MockIRepositoryBaselineRepo{ctrl: ctrl}
+	mock.recorder = &MockIRepositoryBaselineRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIRepositoryBaselineRepo) EXPECT() *MockIRepositoryBaselineRepoMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockIRepositoryBaselineRepo) Delete(ctx context.Context, record *models.RepositoryBaseline) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIRepositoryBaselineRepoMockRecorder) Delete(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIRepositoryBaselineRepo)(nil).Delete), ctx, record)
+}
+
+// GetByRepositoryID mocks base method.
+func (m *MockIRepositoryBaselineRepo) GetByRepositoryID(ctx context.Context, repositoryID int64) (*models.RepositoryBaseline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRepositoryID", ctx, repositoryID)
+	ret0, _ := ret[0].(*models.RepositoryBaseline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRepositoryID indicates an expected call of GetByRepositoryID.
+func (mr *MockIRepositoryBaselineRepoMockRecorder) GetByRepositoryID(ctx, repositoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRepositoryID", reflect.TypeOf((*MockIRepositoryBaselineRepo)(nil).GetByRepositoryID), ctx, repositoryID)
+}
+
+// Upsert mocks base method.
+func (m *MockIRepositoryBaselineRepo) Upsert(ctx context.Context, record *models.RepositoryBaseline) (*models.RepositoryBaseline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, record)
+	ret0, _ := ret[0].(*models.RepositoryBaseline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockIRepositoryBaselineRepoMockRecorder) Upsert(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockIRepositoryBaselineRepo)(nil).Upsert), ctx, record)
+}
+
 // MockIScanRepo is a mock of IScanRepo interface.
 type MockIScanRepo struct {
 	ctrl     *gomock.Controller
@@ -281,6 +457,21 @@ func (mr *MockIScanRepoMockRecorder) MarkStaleScansAsFailure(ctx, maxMinutes int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkStaleScansAsFailure", reflect.TypeOf((*MockIScanRepo)(nil).MarkStaleScansAsFailure), ctx, maxMinutes)
 }
 
+// UpdateStaleFailureWithMap mocks base method.
+func (m *MockIScanRepo) UpdateStaleFailureWithMap(ctx context.Context, record *models.Scan, params map[string]interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStaleFailureWithMap", ctx, record, params)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStaleFailureWithMap indicates an expected call of UpdateStaleFailureWithMap.
+func (mr *MockIScanRepoMockRecorder) UpdateStaleFailureWithMap(ctx, record, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStaleFailureWithMap", reflect.TypeOf((*MockIScanRepo)(nil).UpdateStaleFailureWithMap), ctx, record, params)
+}
+
 // UpdateWithMap mocks base method.
 func (m *MockIScanRepo) UpdateWithMap(ctx context.Context, record *models.Scan, params map[string]interface{}) error {
 	m.ctrl.T.Helper()
@@ -294,3 +485,128 @@ func (mr *MockIScanRepoMockRecorder) UpdateWithMap(ctx, record, params interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithMap", reflect.TypeOf((*MockIScanRepo)(nil).UpdateWithMap), ctx, record, params)
 }
+
+// UpdateWithMapFromStatuses mocks base method.
+func (m *MockIScanRepo) UpdateWithMapFromStatuses(ctx context.Context, record *models.Scan, statuses []string, params map[string]interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithMapFromStatuses", ctx, record, statuses, params)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWithMapFromStatuses indicates an expected call of UpdateWithMapFromStatuses.
+func (mr *MockIScanRepoMockRecorder) UpdateWithMapFromStatuses(ctx, record, statuses, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithMapFromStatuses", reflect.TypeOf((*MockIScanRepo)(nil).UpdateWithMapFromStatuses), ctx, record, statuses, params)
+}
+
+// MockIFindingRepo is a mock of IFindingRepo interface.
+type MockIFindingRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockIFindingRepoMockRecorder
+}
+
+// MockIFindingRepoMockRecorder is the mock recorder for MockIFindingRepo.
+type MockIFindingRepoMockRecorder struct {
+	mock *MockIFindingRepo
+}
+
+// NewMockIFindingRepo creates a new mock instance.
+func NewMockIFindingRepo(ctrl *gomock.Controller) *MockIFindingRepo {
+	mock := &MockIFindingRepo{ctrl: ctrl}
+	mock.recorder = &MockIFindingRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIFindingRepo) EXPECT() *MockIFindingRepoMockRecorder {
+	return m.recorder
+}
+
+// CreateIgnoringDuplicates mocks base method.
+func (m *MockIFindingRepo) CreateIgnoringDuplicates(ctx context.Context, records []*models.FindingRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIgnoringDuplicates", ctx, records)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIgnoringDuplicates indicates an expected call of CreateIgnoringDuplicates.
+func (mr *MockIFindingRepoMockRecorder) CreateIgnoringDuplicates(ctx, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIgnoringDuplicates", reflect.TypeOf((*MockIFindingRepo)(nil).CreateIgnoringDuplicates), ctx, records)
+}
+
+// GetByID mocks base method.
+func (m *MockIFindingRepo) GetByID(ctx context.Context, id int64) (*models.FindingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.FindingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockIFindingRepoMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockIFindingRepo)(nil).GetByID), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockIFindingRepo) List(ctx context.Context, size, page int, filter *models.FindingFilter) ([]*models.FindingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, size, page, filter)
+	ret0, _ := ret[0].([]*models.FindingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockIFindingRepoMockRecorder) List(ctx, size, page, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockIFindingRepo)(nil).List), ctx, size, page, filter)
+}
+
+// ListByFingerprints mocks base method.
+func (m *MockIFindingRepo) ListByFingerprints(ctx context.Context, repositoryID int64, fingerprints []string) ([]*models.FindingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByFingerprints", ctx, repositoryID, fingerprints)
+	ret0, _ := ret[0].([]*models.FindingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByFingerprints indicates an expected call of ListByFingerprints.
+func (mr *MockIFindingRepoMockRecorder) ListByFingerprints(ctx, repositoryID, fingerprints interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByFingerprints", reflect.TypeOf((*MockIFindingRepo)(nil).ListByFingerprints), ctx, repositoryID, fingerprints)
+}
+
+// MarkSeen mocks base method.
+func (m *MockIFindingRepo) MarkSeen(ctx context.Context, scanID int64, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkSeen", ctx, scanID, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkSeen indicates an expected call of MarkSeen.
+func (mr *MockIFindingRepoMockRecorder) MarkSeen(ctx, scanID, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSeen", reflect.TypeOf((*MockIFindingRepo)(nil).MarkSeen), ctx, scanID, ids)
+}
+
+// UpdateWithMap mocks base method.
+func (m *MockIFindingRepo) UpdateWithMap(ctx context.Context, record *models.FindingRecord, params map[string]interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithMap", ctx, record, params)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWithMap indicates an expected call of UpdateWithMap.
+func (mr *MockIFindingRepoMockRecorder) UpdateWithMap(ctx, record, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithMap", reflect.TypeOf((*MockIFindingRepo)(nil).UpdateWithMap), ctx, record, params)
+}
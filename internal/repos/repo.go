@@ -80,3 +80,15 @@ func (r *Repo) Repository() IRepositoryRepo {
 func (r *Repo) Scan() IScanRepo {
 	return NewScanSQLRepo(r.db)
 }
+
+func (r *Repo) RepositoryCredential() IRepositoryCredentialRepo {
+	return NewRepositoryCredentialSQLRepo(r.db)
+}
+
+func (r *Repo) RepositoryBaseline() IRepositoryBaselineRepo {
+	return NewRepositoryBaselineSQLRepo(r.db)
+}
+
+func (r *Repo) Finding() IFindingRepo {
+	return NewFindingSQLRepo(r.db)
+}
@@ -2,6 +2,7 @@ package repos
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -50,6 +51,36 @@ func (r *ScanSQLRepo) UpdateWithMap(
 		Error
 }
 
+// UpdateWithMapFromStatuses updates the scan only while its status is one of the given
+// statuses, so that of two writers moving the same scan only the first one does. It
+// tells whether the scan was updated.
+func (r *ScanSQLRepo) UpdateWithMapFromStatuses(
+	ctx context.Context,
+	record *models.Scan,
+	statuses []string,
+	params map[string]interface{},
+) (bool, error) {
+	result := r.dbWithContext(ctx).
+		Model(record).
+		Where("status IN (?)", statuses).
+		Updates(params)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateStaleFailureWithMap updates a scan only while it is failed by the stale checker.
+// It tells whether the scan was updated.
+func (r *ScanSQLRepo) UpdateStaleFailureWithMap(
+	ctx context.Context,
+	record *models.Scan,
+	params map[string]interface{},
+) (bool, error) {
+	result := r.dbWithContext(ctx).
+		Model(record).
+		Where("status = ? AND error_code = ?", models.ScanStatusFailure, models.ScanErrorStale).
+		Updates(params)
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *ScanSQLRepo) Delete(ctx context.Context, record *models.Scan) error {
 	return r.dbWithContext(ctx).Delete(record).Error
 }
@@ -63,8 +94,13 @@ func (r *ScanSQLRepo) MarkStaleScansAsFailure(
 	return r.dbWithContext(ctx).
 		Model(models.Scan{}).
 		Where("status IN (?) AND (queued_at < ? OR scanning_at < ?)",
-			[]string{models.ScanStatusQueued, models.ScanStatusInProgress}, staleTime, staleTime).
-		Updates(models.Scan{Status: models.ScanStatusFailure, FinishedAt: &timeNow}).Error
+			models.PreviousScanStatuses(models.ScanStatusFailure), staleTime, staleTime).
+		Updates(models.Scan{
+			Status:       models.ScanStatusFailure,
+			ErrorCode:    models.ScanErrorStale,
+			ErrorMessage: fmt.Sprintf("the scan made no progress for %d minutes", maxMinutes),
+			FinishedAt:   &timeNow,
+		}).Error
 }
 
 func (r *ScanSQLRepo) List(
@@ -102,5 +138,17 @@ func (r *ScanSQLRepo) buildQueryFromFilter(
 		query = query.Where("status = ?", filter.Status)
 	}
 
+	if filter.Ref != nil {
+		query = query.Where("ref = ?", filter.Ref)
+	}
+
+	if filter.History != nil {
+		query = query.Where("history = ?", filter.History)
+	}
+
+	if filter.BeforeID != nil {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
 	return query
 }
@@ -21,7 +21,12 @@ type Server struct {
 	httpServer *http.Server
 }
 
-func NewServer(cfg *config.App, kafkaWriter kafka.IWriter, kafkaReader *kafka.Reader) *Server {
+func NewServer(
+	cfg *config.App,
+	kafkaWriter kafka.IWriter,
+	cancelWriter kafka.IWriter,
+	kafkaReader *kafka.Reader,
+) *Server {
 	logger, _ := zap.NewProduction()
 	defer func() {
 		_ = logger.Sync()
@@ -34,7 +39,7 @@ func NewServer(cfg *config.App, kafkaWriter kafka.IWriter, kafkaReader *kafka.Re
 	router := gin.New()
 	return &Server{
 		cfg:    cfg,
-		apiSvc: api.NewScanService(bs, kafkaWriter, kafkaReader),
+		apiSvc: api.NewScanService(bs, kafkaWriter, cancelWriter, kafkaReader),
 		router: router,
 	}
 }
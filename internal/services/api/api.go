@@ -9,6 +9,8 @@ import (
 	"github.com/vumanhcuongit/scan/internal/services/base"
 	"github.com/vumanhcuongit/scan/pkg/kafka"
 	"github.com/vumanhcuongit/scan/pkg/models"
+	"github.com/vumanhcuongit/scan/pkg/sarif"
+	"github.com/vumanhcuongit/scan/pkg/secrets"
 	"go.uber.org/zap"
 )
 
@@ -23,29 +25,67 @@ type IScanService interface {
 	UpdateRepository(ctx context.Context, repositoryID int64, request *UpdateRepositoryRequest) (*models.Repository, error)
 	DeleteRepository(ctx context.Context, repositoryID int64) error
 
+	// credentials of private repositories
+	SetRepositoryCredential(ctx context.Context, repositoryID int64, request *SetRepositoryCredentialRequest) (*models.RepositoryCredential, error)
+	GetRepositoryCredential(ctx context.Context, repositoryID int64) (*models.RepositoryCredential, error)
+	DeleteRepositoryCredential(ctx context.Context, repositoryID int64) error
+
+	// baselines accepting the existing findings of repositories
+	SetRepositoryBaseline(ctx context.Context, repositoryID int64, request *SetRepositoryBaselineRequest) (*models.RepositoryBaseline, error)
+	GetRepositoryBaseline(ctx context.Context, repositoryID int64) (*models.RepositoryBaseline, error)
+	DeleteRepositoryBaseline(ctx context.Context, repositoryID int64) error
+
 	// scan
 	ListScans(ctx context.Context, request *ListScansRequest) ([]*models.Scan, error)
 	TriggerScan(ctx context.Context, request *TriggerScanRequest) (*models.Scan, error)
+	GetScan(ctx context.Context, scanID int64, request *GetScanRequest) (map[string]json.RawMessage, error)
+	GetScanReport(ctx context.Context, scanID int64, request *GetScanReportRequest) (*sarif.Log, error)
+	ListSuppressedFindings(ctx context.Context, scanID int64) ([]models.Finding, error)
+	GetScanDiff(ctx context.Context, scanID int64, request *GetScanDiffRequest) (*models.ScanDiff, error)
+	CancelScan(ctx context.Context, scanID int64) (*models.Scan, error)
+	RetryScan(ctx context.Context, scanID int64) (*models.Scan, error)
+	RerunScan(ctx context.Context, scanID int64) (*models.Scan, error)
 	UpdateScan(ctx context.Context, scan *models.Scan, request *UpdateScanRequest) (*models.Scan, error)
 	HandleResultMessage(ctx context.Context, result *models.ScanResultMessage) error
+
+	// findings
+	ListFindings(ctx context.Context, request *ListFindingsRequest) ([]*models.FindingRecord, error)
+	UpdateFinding(ctx context.Context, findingID int64, request *UpdateFindingRequest) (*models.FindingRecord, error)
 }
 
 type ScanService struct {
 	repo repos.IRepo
 	base.Service
-	scanChecker *ScanChecker
-	kafkaReader *kafka.Reader
-	kafkaWriter kafka.IWriter
+	scanChecker  *ScanChecker
+	kafkaReader  *kafka.Reader
+	kafkaWriter  kafka.IWriter
+	cancelWriter kafka.IWriter // tells the execution service to stop scans
+	box          *secrets.Box  // encrypts the credentials, nil when no encryption key is configured
 }
 
-func NewScanService(bs *base.Service, kafkaWriter kafka.IWriter, kafkaReader *kafka.Reader) IScanService {
+func NewScanService(
+	bs *base.Service,
+	kafkaWriter kafka.IWriter,
+	cancelWriter kafka.IWriter,
+	kafkaReader *kafka.Reader,
+) IScanService {
 	scanChecker := NewScanChecker(bs.Repo(), &bs.Config().ScanChecker)
+	var box *secrets.Box
+	if key := bs.Config().Secrets.EncryptionKey; key != "" {
+		var err error
+		box, err = secrets.NewBox(key)
+		if err != nil {
+			panic(err)
+		}
+	}
 	return &ScanService{
-		Service:     *bs,
-		repo:        bs.Repo(),
-		scanChecker: scanChecker,
-		kafkaWriter: kafkaWriter,
-		kafkaReader: kafkaReader,
+		Service:      *bs,
+		repo:         bs.Repo(),
+		scanChecker:  scanChecker,
+		kafkaWriter:  kafkaWriter,
+		cancelWriter: cancelWriter,
+		kafkaReader:  kafkaReader,
+		box:          box,
 	}
 }
 
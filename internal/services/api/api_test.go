@@ -16,6 +16,6 @@ func TestNewScanService(t *testing.T) {
 	}
 	baseService := &base.Service{}
 	baseService.SetConfig(cfg)
-	scanService := NewScanService(baseService, &kafka.Writer{}, &kafka.Reader{})
+	scanService := NewScanService(baseService, &kafka.Writer{}, &kafka.Writer{}, &kafka.Reader{})
 	require.NotNil(t, scanService)
 }
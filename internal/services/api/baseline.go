@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type SetRepositoryBaselineRequest struct {
+	Fingerprints []string `json:"fingerprints"`
+	ScanID       *int64   `json:"scan_id"` // also accept every failing finding of this successful scan of the repository
+}
+
+// SetRepositoryBaseline replaces the uploaded baseline of a repository. Findings it
+// accepts are marked baselined in the later scans and do not count as failing.
+func (s *ScanService) SetRepositoryBaseline(
+	ctx context.Context,
+	repositoryID int64,
+	request *SetRepositoryBaselineRequest,
+) (*models.RepositoryBaseline, error) {
+	log := zap.S()
+	log.Infof("starting to set baseline of repository %d with %d fingerprints", repositoryID, len(request.Fingerprints))
+
+	if len(request.Fingerprints) == 0 && request.ScanID == nil {
+		log.Warnf("empty baseline")
+		return nil, status.Error(codes.InvalidArgument, "fingerprints or scan_id is required")
+	}
+	baseline := &models.Baseline{Fingerprints: request.Fingerprints}
+	if err := baseline.Validate(); err != nil {
+		log.Warnf("invalid baseline, err: %+v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	repository, err := s.GetRepository(ctx, repositoryID)
+	if err != nil {
+		log.Warnf("failed to get repository, err: %+v", err)
+		return nil, err
+	}
+
+	if request.ScanID != nil {
+		scan, err := s.getSuccessfulScan(ctx, *request.ScanID)
+		if err != nil {
+			log.Warnf("failed to get scan, err: %+v", err)
+			return nil, err
+		}
+		if scan.RepositoryID != repository.ID {
+			log.Warnf("scan %d is of another repository", scan.ID)
+			return nil, status.Errorf(codes.InvalidArgument, "scan %d is not a scan of repository %d", scan.ID, repository.ID)
+		}
+		findings, err := scan.ParseFindings()
+		if err != nil {
+			log.Warnf("failed to parse findings, err: %+v", err)
+			return nil, err
+		}
+		for _, finding := range findings {
+			if !finding.Suppressed && finding.Fingerprint != "" {
+				baseline.Fingerprints = append(baseline.Fingerprints, finding.Fingerprint)
+			}
+		}
+	}
+
+	fingerprints, err := json.Marshal(uniqueStrings(baseline.Fingerprints))
+	if err != nil {
+		log.Warnf("failed to marshal fingerprints, err: %+v", err)
+		return nil, err
+	}
+	repositoryBaseline, err := s.repo.RepositoryBaseline().Upsert(ctx, &models.RepositoryBaseline{
+		RepositoryID: repository.ID,
+		Fingerprints: fingerprints,
+	})
+	if err != nil {
+		log.Warnf("failed to save baseline, err: %+v", err)
+		return nil, err
+	}
+
+	return repositoryBaseline, nil
+}
+
+func (s *ScanService) GetRepositoryBaseline(ctx context.Context, repositoryID int64) (*models.RepositoryBaseline, error) {
+	log := zap.S()
+	log.Infof("starting to get baseline of repository %d", repositoryID)
+
+	baseline, err := s.repo.RepositoryBaseline().GetByRepositoryID(ctx, repositoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Warnf("repository %d has no baseline", repositoryID)
+		return nil, status.Errorf(codes.NotFound, "repository %d has no baseline", repositoryID)
+	}
+	if err != nil {
+		log.Warnf("failed to get baseline, err: %+v", err)
+		return nil, err
+	}
+
+	return baseline, nil
+}
+
+func (s *ScanService) DeleteRepositoryBaseline(ctx context.Context, repositoryID int64) error {
+	log := zap.S()
+	log.Infof("starting to delete baseline of repository %d", repositoryID)
+
+	baseline, err := s.GetRepositoryBaseline(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.RepositoryBaseline().Delete(ctx, baseline)
+	if err != nil {
+		log.Warnf("failed to delete baseline, err: %+v", err)
+		return err
+	}
+
+	return nil
+}
+
+// getRepositoryBaseline returns nil when no baseline was uploaded for the repository.
+func (s *ScanService) getRepositoryBaseline(ctx context.Context, repositoryID int64) (*models.Baseline, error) {
+	repositoryBaseline, err := s.repo.RepositoryBaseline().GetByRepositoryID(ctx, repositoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return repositoryBaseline.Baseline()
+}
+
+func uniqueStrings(values []string) []string {
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
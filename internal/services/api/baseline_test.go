@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vumanhcuongit/scan/internal/repos"
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type baselineSuite struct {
+	suite.Suite
+
+	mockCtrl       *gomock.Controller
+	repo           *repos.MockIRepo
+	scanRepo       *repos.MockIScanRepo
+	repositoryRepo *repos.MockIRepositoryRepo
+	baselineRepo   *repos.MockIRepositoryBaselineRepo
+	scanService    *ScanService
+}
+
+func TestBaselineSuite(t *testing.T) {
+	suite.Run(t, &baselineSuite{})
+}
+
+func (s *baselineSuite) SetupSuite() {
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	undo := zap.ReplaceGlobals(logger)
+	defer undo()
+}
+
+func (s *baselineSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repo = repos.NewMockIRepo(s.mockCtrl)
+	s.scanRepo = repos.NewMockIScanRepo(s.mockCtrl)
+	s.repositoryRepo = repos.NewMockIRepositoryRepo(s.mockCtrl)
+	s.baselineRepo = repos.NewMockIRepositoryBaselineRepo(s.mockCtrl)
+	s.scanService = &ScanService{repo: s.repo}
+}
+
+func (s *baselineSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func (s *baselineSuite) TestSetRepositoryBaseline() {
+	repository := &models.Repository{ID: 1}
+	uploaded := strings.Repeat("a", 64)
+	accepted := strings.Repeat("b", 64)
+	scan := &models.Scan{
+		ID:           4,
+		RepositoryID: repository.ID,
+		Status:       models.ScanStatusSuccess,
+		Findings: []byte(`[{"ruleId":"G101","fingerprint":"` + accepted + `"},` +
+			`{"ruleId":"G101","fingerprint":"` + uploaded + `"},` +
+			`{"ruleId":"G101","fingerprint":"` + strings.Repeat("c", 64) + `","suppressed":true}]`),
+	}
+	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repository.ID).Return(repository, nil)
+	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+	s.baselineRepo.EXPECT().Upsert(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.RepositoryBaseline) (*models.RepositoryBaseline, error) {
+			s.Require().Equal(repository.ID, record.RepositoryID)
+			baseline, err := record.Baseline()
+			s.Require().NoError(err)
+			s.Require().Equal([]string{uploaded, accepted}, baseline.Fingerprints)
+			return record, nil
+		},
+	)
+	s.repo.EXPECT().RepositoryBaseline().Return(s.baselineRepo)
+
+	_, err := s.scanService.SetRepositoryBaseline(context.Background(), repository.ID, &SetRepositoryBaselineRequest{
+		Fingerprints: []string{uploaded},
+		ScanID:       &scan.ID,
+	})
+	s.Require().NoError(err)
+}
+
+func (s *baselineSuite) TestSetRepositoryBaselineWithInvalidParams() {
+	_, err := s.scanService.SetRepositoryBaseline(context.Background(), 1, &SetRepositoryBaselineRequest{})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+
+	_, err = s.scanService.SetRepositoryBaseline(context.Background(), 1, &SetRepositoryBaselineRequest{
+		Fingerprints: []string{"G101:config.py"},
+	})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *baselineSuite) TestSetRepositoryBaselineWithScanOfAnotherRepository() {
+	scanID := int64(4)
+	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), int64(1)).Return(&models.Repository{ID: 1}, nil)
+	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).
+		Return(&models.Scan{ID: scanID, RepositoryID: 2, Status: models.ScanStatusSuccess}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.SetRepositoryBaseline(context.Background(), 1, &SetRepositoryBaselineRequest{ScanID: &scanID})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *baselineSuite) TestGetRepositoryBaselineWithoutBaseline() {
+	s.baselineRepo.EXPECT().GetByRepositoryID(gomock.Any(), int64(1)).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryBaseline().Return(s.baselineRepo)
+
+	_, err := s.scanService.GetRepositoryBaseline(context.Background(), 1)
+	s.Require().Error(err)
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
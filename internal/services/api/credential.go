@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type SetRepositoryCredentialRequest struct {
+	Kind     string `json:"kind" binding:"required"` // token or deploy_key
+	Username string `json:"username"`                // user of the token over https, ignored for deploy keys
+	Secret   string `json:"secret" binding:"required"`
+}
+
+// String keeps the secret out of the logs.
+func (r *SetRepositoryCredentialRequest) String() string {
+	return "{Kind:" + r.Kind + " Username:" + r.Username + "}"
+}
+
+// SetRepositoryCredential stores the credential of a private repository, setting it
+// again rotates the secret.
+func (s *ScanService) SetRepositoryCredential(
+	ctx context.Context,
+	repositoryID int64,
+	request *SetRepositoryCredentialRequest,
+) (*models.RepositoryCredential, error) {
+	log := zap.S()
+	log.Infof("starting to set credential of repository %d with request %s", repositoryID, request)
+
+	if !models.IsValidCredentialKind(request.Kind) {
+		log.Warnf("invalid credential kind %s", request.Kind)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid credential kind: %s", request.Kind)
+	}
+	secret := strings.TrimSpace(request.Secret)
+	if request.Kind == models.CredentialKindDeployKey {
+		if !strings.HasPrefix(secret, "-----BEGIN ") {
+			log.Warnf("deploy key is not a PEM encoded private key")
+			return nil, status.Error(codes.InvalidArgument, "deploy key must be a PEM encoded private key")
+		}
+		// ssh refuses keys without their final newline
+		secret += "\n"
+	}
+	if s.box == nil {
+		log.Warnf("encryption key is not configured")
+		return nil, status.Error(codes.FailedPrecondition, "credentials are disabled, the encryption key is not configured")
+	}
+
+	repository, err := s.GetRepository(ctx, repositoryID)
+	if err != nil {
+		log.Warnf("failed to get repository, err: %+v", err)
+		return nil, err
+	}
+
+	encryptedSecret, err := s.box.Seal(secret)
+	if err != nil {
+		log.Warnf("failed to encrypt secret, err: %+v", err)
+		return nil, err
+	}
+
+	credential, err := s.repo.RepositoryCredential().Upsert(ctx, &models.RepositoryCredential{
+		RepositoryID:    repository.ID,
+		Kind:            request.Kind,
+		Username:        request.Username,
+		EncryptedSecret: encryptedSecret,
+	})
+	if err != nil {
+		log.Warnf("failed to save credential, err: %+v", err)
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+func (s *ScanService) GetRepositoryCredential(ctx context.Context, repositoryID int64) (*models.RepositoryCredential, error) {
+	log := zap.S()
+	log.Infof("starting to get credential of repository %d", repositoryID)
+
+	credential, err := s.getRepositoryCredential(ctx, repositoryID)
+	if err != nil {
+		log.Warnf("failed to get credential, err: %+v", err)
+		return nil, err
+	}
+	if credential == nil {
+		return nil, status.Errorf(codes.NotFound, "repository %d has no credential", repositoryID)
+	}
+
+	return credential, nil
+}
+
+func (s *ScanService) DeleteRepositoryCredential(ctx context.Context, repositoryID int64) error {
+	log := zap.S()
+	log.Infof("starting to delete credential of repository %d", repositoryID)
+
+	credential, err := s.GetRepositoryCredential(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.RepositoryCredential().Delete(ctx, credential)
+	if err != nil {
+		log.Warnf("failed to delete credential, err: %+v", err)
+		return err
+	}
+
+	return nil
+}
+
+// getRepositoryCredential returns nil when the repository is public.
+func (s *ScanService) getRepositoryCredential(ctx context.Context, repositoryID int64) (*models.RepositoryCredential, error) {
+	credential, err := s.repo.RepositoryCredential().GetByRepositoryID(ctx, repositoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
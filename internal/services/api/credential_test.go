@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vumanhcuongit/scan/internal/repos"
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"github.com/vumanhcuongit/scan/pkg/secrets"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type credentialSuite struct {
+	suite.Suite
+
+	mockCtrl       *gomock.Controller
+	repo           *repos.MockIRepo
+	repositoryRepo *repos.MockIRepositoryRepo
+	credentialRepo *repos.MockIRepositoryCredentialRepo
+	box            *secrets.Box
+	scanService    *ScanService
+}
+
+func TestCredentialSuite(t *testing.T) {
+	suite.Run(t, &credentialSuite{})
+}
+
+func (s *credentialSuite) SetupSuite() {
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	undo := zap.ReplaceGlobals(logger)
+	defer undo()
+}
+
+func (s *credentialSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repo = repos.NewMockIRepo(s.mockCtrl)
+	s.repositoryRepo = repos.NewMockIRepositoryRepo(s.mockCtrl)
+	s.credentialRepo = repos.NewMockIRepositoryCredentialRepo(s.mockCtrl)
+	box, err := secrets.NewBox(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", secrets.KeySize))))
+	s.Require().NoError(err)
+	s.box = box
+	s.scanService = &ScanService{repo: s.repo, box: s.box}
+}
+
+func (s *credentialSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func (s *credentialSuite) TestSetRepositoryCredential() {
+	repository := &models.Repository{ID: 1}
+	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repository.ID).Return(repository, nil)
+	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
+	s.credentialRepo.EXPECT().Upsert(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.RepositoryCredential) (*models.RepositoryCredential, error) {
+			s.Require().Equal(repository.ID, record.RepositoryID)
+			s.Require().NotContains(record.EncryptedSecret, "glpat-secret")
+			secret, err := s.box.Open(record.EncryptedSecret)
+			s.Require().NoError(err)
+			s.Require().Equal("glpat-secret", secret)
+			return record, nil
+		},
+	)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
+
+	credential, err := s.scanService.SetRepositoryCredential(context.Background(), repository.ID, &SetRepositoryCredentialRequest{
+		Kind:     models.CredentialKindToken,
+		Username: "oauth2",
+		Secret:   " glpat-secret\n",
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(models.CredentialKindToken, credential.Kind)
+	s.Require().Equal("oauth2", credential.Username)
+}
+
+func (s *credentialSuite) TestSetRepositoryCredentialWithInvalidRequest() {
+	for _, request := range []*SetRepositoryCredentialRequest{
+		{Kind: "password", Secret: "hunter2"},
+		{Kind: models.CredentialKindDeployKey, Secret: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5 public@key"},
+	} {
+		_, err := s.scanService.SetRepositoryCredential(context.Background(), 1, request)
+		s.Require().Error(err)
+		s.Require().Equal(codes.InvalidArgument, status.Code(err))
+		s.Require().NotContains(err.Error(), request.Secret)
+	}
+}
+
+func (s *credentialSuite) TestSetRepositoryCredentialWithoutEncryptionKey() {
+	s.scanService.box = nil
+
+	_, err := s.scanService.SetRepositoryCredential(context.Background(), 1, &SetRepositoryCredentialRequest{
+		Kind:   models.CredentialKindToken,
+		Secret: "glpat-secret",
+	})
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *credentialSuite) TestGetRepositoryCredentialWithoutCredential() {
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), int64(1)).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
+
+	_, err := s.scanService.GetRepositoryCredential(context.Background(), 1)
+	s.Require().Error(err)
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *credentialSuite) TestDeleteRepositoryCredential() {
+	credential := &models.RepositoryCredential{ID: 3, RepositoryID: 1}
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), credential.RepositoryID).Return(credential, nil)
+	s.credentialRepo.EXPECT().Delete(gomock.Any(), credential).Return(nil)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo).Times(2)
+
+	err := s.scanService.DeleteRepositoryCredential(context.Background(), credential.RepositoryID)
+	s.Require().NoError(err)
+}
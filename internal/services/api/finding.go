@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/vumanhcuongit/scan/internal/repos"
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type UpdateFindingRequest struct {
+	State   string `json:"state" binding:"required"` // open, acknowledged, false_positive, wont_fix or resolved
+	Comment string `json:"comment"`
+	Actor   string `json:"actor" binding:"required"` // who made the triage decision
+}
+
+type ListFindingsRequest struct {
+	RepositoryID *int64  `json:"repository_id" form:"repository_id"`
+	RuleID       *string `json:"rule_id" form:"rule_id"`
+	Severity     *string `json:"severity" form:"severity"`
+	Path         *string `json:"path" form:"path"` // prefix of the paths, e.g. config/
+	State        *string `json:"state" form:"state"`
+	Size         int     `json:"size" form:"size"`
+	Page         int     `json:"page" form:"page"`
+}
+
+// ListFindings queries the findings tracked across the scans of every repository.
+func (s *ScanService) ListFindings(ctx context.Context, request *ListFindingsRequest) ([]*models.FindingRecord, error) {
+	log := zap.S()
+	log.Infof("starting to list findings with request %+v", request)
+
+	if request.State != nil && !models.IsValidFindingState(*request.State) {
+		log.Warnf("invalid finding state %s", *request.State)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid finding state: %s", *request.State)
+	}
+
+	filter := &models.FindingFilter{
+		RepositoryID: request.RepositoryID,
+		RuleID:       request.RuleID,
+		PathPrefix:   request.Path,
+		State:        request.State,
+	}
+	if request.Severity != nil {
+		// rules report their severity in upper case
+		severity := strings.ToUpper(*request.Severity)
+		filter.Severity = &severity
+	}
+	findings, err := s.repo.Finding().List(ctx, request.Size, request.Page, filter)
+	if err != nil {
+		log.Warnf("failed to list findings, err: %+v", err)
+		return nil, err
+	}
+
+	return findings, nil
+}
+
+// UpdateFinding records a triage decision on a finding, it applies to every later scan of
+// the repository reporting the same finding.
+func (s *ScanService) UpdateFinding(
+	ctx context.Context,
+	findingID int64,
+	request *UpdateFindingRequest,
+) (*models.FindingRecord, error) {
+	log := zap.S()
+	log.Infof("starting to update finding %d with request %+v", findingID, request)
+
+	if !models.IsValidFindingState(request.State) {
+		log.Warnf("invalid finding state %s", request.State)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid finding state: %s", request.State)
+	}
+
+	finding, err := s.repo.Finding().GetByID(ctx, findingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Warnf("finding %d not found", findingID)
+		return nil, status.Errorf(codes.NotFound, "finding %d not found", findingID)
+	}
+	if err != nil {
+		log.Warnf("failed to get finding, err: %+v", err)
+		return nil, err
+	}
+
+	timeNow := time.Now()
+	changesets := map[string]interface{}{
+		"state":      request.State,
+		"comment":    request.Comment,
+		"actor":      request.Actor,
+		"triaged_at": &timeNow,
+	}
+	err = s.repo.Finding().UpdateWithMap(ctx, finding, changesets)
+	if err != nil {
+		log.Warnf("failed to update finding, err: %+v", err)
+		return nil, err
+	}
+	finding.State = request.State
+	finding.Comment = request.Comment
+	finding.Actor = request.Actor
+	finding.TriagedAt = &timeNow
+
+	return finding, nil
+}
+
+// updateScanWithFindings stores the findings of a scan and tracks them in a transaction,
+// so that nothing is tracked when the scan cannot move to the status of the request anymore.
+func (s *ScanService) updateScanWithFindings(
+	ctx context.Context,
+	scan *models.Scan,
+	request *UpdateScanRequest,
+	rawFindings []byte,
+) (*models.Scan, error) {
+	log := zap.S()
+	var updatedScan *models.Scan
+	err := s.repo.WithTransaction(ctx, func(repo repos.IRepo) error {
+		txService := *s
+		txService.repo = repo
+
+		findings, failingFindings, err := txService.prepareFindings(ctx, scan, rawFindings)
+		if err != nil {
+			log.Warnf("failed to prepare findings, err: %+v", err)
+			return err
+		}
+		request.Findings = findings
+		request.FailingFindings = &failingFindings
+
+		updatedScan, err = txService.UpdateScan(ctx, scan, request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedScan, nil
+}
+
+// prepareFindings marks the findings of a scan the uploaded baseline of the repository
+// accepts and tracks them, it returns the annotated findings and how many of them fail.
+func (s *ScanService) prepareFindings(ctx context.Context, scan *models.Scan, rawFindings []byte) ([]byte, int, error) {
+	log := zap.S()
+	findings, err := (&models.Scan{Findings: rawFindings}).ParseFindings()
+	if err != nil {
+		log.Warnf("failed to parse findings, err: %+v", err)
+		return nil, 0, err
+	}
+
+	fingerprinted := false
+	for _, finding := range findings {
+		fingerprinted = fingerprinted || finding.Fingerprint != ""
+	}
+	if !fingerprinted {
+		return rawFindings, models.CountFailingFindings(findings), nil
+	}
+
+	baseline, err := s.getRepositoryBaseline(ctx, scan.RepositoryID)
+	if err != nil {
+		log.Warnf("failed to get baseline, err: %+v", err)
+		return nil, 0, err
+	}
+	baseline.Apply(findings)
+
+	err = s.trackFindings(ctx, scan, findings)
+	if err != nil {
+		log.Warnf("failed to track findings, err: %+v", err)
+		return nil, 0, err
+	}
+
+	preparedFindings, err := json.Marshal(findings)
+	if err != nil {
+		log.Warnf("failed to marshal findings, err: %+v", err)
+		return nil, 0, err
+	}
+
+	return preparedFindings, models.CountFailingFindings(findings), nil
+}
+
+// trackFindings starts tracking the findings a scan reported for the first time and
+// annotates the findings with the id and the triage state of their record.
+// Suppressed findings and findings without fingerprint are not tracked.
+func (s *ScanService) trackFindings(ctx context.Context, scan *models.Scan, findings []models.Finding) error {
+	log := zap.S()
+	fingerprints := []string{}
+	records := []*models.FindingRecord{}
+	seen := map[string]bool{}
+	for i := range findings {
+		if findings[i].Suppressed || findings[i].Fingerprint == "" || seen[findings[i].Fingerprint] {
+			continue
+		}
+		// a fingerprint is tracked once even if the scan reports it several times
+		seen[findings[i].Fingerprint] = true
+		fingerprints = append(fingerprints, findings[i].Fingerprint)
+		records = append(records, models.NewFindingRecord(scan.RepositoryID, scan.ID, &findings[i]))
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	err := s.repo.Finding().CreateIgnoringDuplicates(ctx, records)
+	if err != nil {
+		log.Warnf("failed to create findings, err: %+v", err)
+		return err
+	}
+
+	trackedFindings, err := s.repo.Finding().ListByFingerprints(ctx, scan.RepositoryID, fingerprints)
+	if err != nil {
+		log.Warnf("failed to list findings, err: %+v", err)
+		return err
+	}
+	ids := []int64{}
+	for _, trackedFinding := range trackedFindings {
+		ids = append(ids, trackedFinding.ID)
+	}
+	err = s.repo.Finding().MarkSeen(ctx, scan.ID, ids)
+	if err != nil {
+		log.Warnf("failed to mark findings as seen, err: %+v", err)
+		return err
+	}
+
+	trackedFindingByFingerprint := map[string]*models.FindingRecord{}
+	for _, trackedFinding := range trackedFindings {
+		if trackedFinding.State == models.FindingStateResolved {
+			// MarkSeen reopened it
+			trackedFinding.State = models.FindingStateOpen
+		}
+		trackedFindingByFingerprint[trackedFinding.Fingerprint] = trackedFinding
+	}
+	for i := range findings {
+		trackedFinding, found := trackedFindingByFingerprint[findings[i].Fingerprint]
+		if !found || findings[i].Suppressed {
+			continue
+		}
+		findings[i].ID = trackedFinding.ID
+		findings[i].State = trackedFinding.State
+	}
+
+	return nil
+}
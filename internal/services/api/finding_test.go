@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vumanhcuongit/scan/internal/repos"
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type findingSuite struct {
+	suite.Suite
+
+	mockCtrl     *gomock.Controller
+	repo         *repos.MockIRepo
+	scanRepo     *repos.MockIScanRepo
+	findingRepo  *repos.MockIFindingRepo
+	baselineRepo *repos.MockIRepositoryBaselineRepo
+	scanService  *ScanService
+}
+
+func TestFindingSuite(t *testing.T) {
+	suite.Run(t, &findingSuite{})
+}
+
+func (s *findingSuite) SetupSuite() {
+	logger, _ := zap.NewProduction()
+	defer func() {
+		_ = logger.Sync()
+	}()
+	undo := zap.ReplaceGlobals(logger)
+	defer undo()
+}
+
+func (s *findingSuite) SetupTest() {
+	s.mockCtrl = gomock.NewController(s.T())
+	s.repo = repos.NewMockIRepo(s.mockCtrl)
+	s.scanRepo = repos.NewMockIScanRepo(s.mockCtrl)
+	s.findingRepo = repos.NewMockIFindingRepo(s.mockCtrl)
+	s.baselineRepo = repos.NewMockIRepositoryBaselineRepo(s.mockCtrl)
+	s.scanService = &ScanService{repo: s.repo}
+}
+
+func (s *findingSuite) TearDownTest() {
+	s.mockCtrl.Finish()
+}
+
+func (s *findingSuite) TestListFindings() {
+	severity := "high"
+	path := "config/"
+	request := &ListFindingsRequest{Severity: &severity, Path: &path, Size: 20, Page: 1}
+	expectedFindings := []*models.FindingRecord{{ID: 1, Severity: "HIGH", Path: "config/app.yaml"}}
+	s.findingRepo.EXPECT().List(gomock.Any(), request.Size, request.Page, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, size int, page int, filter *models.FindingFilter) ([]*models.FindingRecord, error) {
+			s.Require().Equal("HIGH", *filter.Severity)
+			s.Require().Equal(path, *filter.PathPrefix)
+			s.Require().Nil(filter.RepositoryID)
+			return expectedFindings, nil
+		},
+	)
+	s.repo.EXPECT().Finding().Return(s.findingRepo)
+
+	findings, err := s.scanService.ListFindings(context.Background(), request)
+	s.Require().NoError(err)
+	s.Require().Equal(expectedFindings, findings)
+}
+
+func (s *findingSuite) TestListFindingsWithInvalidState() {
+	state := "closed"
+	_, err := s.scanService.ListFindings(context.Background(), &ListFindingsRequest{State: &state, Size: 20, Page: 1})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *findingSuite) TestUpdateFinding() {
+	finding := &models.FindingRecord{ID: 1, State: models.FindingStateOpen}
+	request := &UpdateFindingRequest{
+		State:   models.FindingStateFalsePositive,
+		Comment: "example key from the docs",
+		Actor:   "alice@example.com",
+	}
+	s.findingRepo.EXPECT().GetByID(gomock.Any(), finding.ID).Return(finding, nil)
+	s.findingRepo.EXPECT().UpdateWithMap(gomock.Any(), finding, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.FindingRecord, params map[string]interface{}) error {
+			s.Require().Equal(request.State, params["state"])
+			s.Require().Equal(request.Comment, params["comment"])
+			s.Require().Equal(request.Actor, params["actor"])
+			s.Require().NotNil(params["triaged_at"])
+			return nil
+		},
+	)
+	s.repo.EXPECT().Finding().Return(s.findingRepo).Times(2)
+
+	updatedFinding, err := s.scanService.UpdateFinding(context.Background(), finding.ID, request)
+	s.Require().NoError(err)
+	s.Require().Equal(models.FindingStateFalsePositive, updatedFinding.State)
+	s.Require().Equal("alice@example.com", updatedFinding.Actor)
+	s.Require().NotNil(updatedFinding.TriagedAt)
+}
+
+func (s *findingSuite) TestUpdateFindingWithInvalidState() {
+	_, err := s.scanService.UpdateFinding(context.Background(), 1, &UpdateFindingRequest{
+		State: "ignored",
+		Actor: "alice@example.com",
+	})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *findingSuite) TestUpdateFindingWithNotFoundRecord() {
+	s.findingRepo.EXPECT().GetByID(gomock.Any(), int64(1)).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().Finding().Return(s.findingRepo)
+
+	_, err := s.scanService.UpdateFinding(context.Background(), 1, &UpdateFindingRequest{
+		State: models.FindingStateAcknowledged,
+		Actor: "alice@example.com",
+	})
+	s.Require().Error(err)
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *findingSuite) TestHandleResultMessageCarriesTriageOverAndAppliesBaseline() {
+	scanID := int64(3)
+	timeNow := time.Now()
+	findings := []models.Finding{
+		{RuleID: "G101", Location: models.Location{Path: "config.py"}, Fingerprint: "known"},
+		{RuleID: "G101", Location: models.Location{Path: "settings.py"}, Fingerprint: "new"},
+		{RuleID: "G101", Location: models.Location{Path: "test.py"}, Fingerprint: "suppressed", Suppressed: true},
+		{RuleID: "G104", Location: models.Location{Path: "key.pem"}, Fingerprint: "resolved"},
+	}
+	rawFindings, err := json.Marshal(findings)
+	s.Require().NoError(err)
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusSuccess,
+		Findings:   rawFindings,
+		FinishedAt: &timeNow,
+	}
+	fingerprints := []string{"known", "new", "resolved"}
+	trackedFindings := []*models.FindingRecord{
+		{ID: 10, Fingerprint: "known", State: models.FindingStateFalsePositive},
+		{ID: 11, Fingerprint: "new", State: models.FindingStateOpen},
+		{ID: 12, Fingerprint: "resolved", State: models.FindingStateResolved},
+	}
+
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).
+		Return(&models.Scan{ID: scanID, RepositoryID: 2, Status: models.ScanStatusInProgress}, nil)
+	s.baselineRepo.EXPECT().GetByRepositoryID(gomock.Any(), int64(2)).
+		Return(&models.RepositoryBaseline{RepositoryID: 2, Fingerprints: []byte(`["new"]`)}, nil)
+	s.repo.EXPECT().RepositoryBaseline().Return(s.baselineRepo)
+	s.findingRepo.EXPECT().CreateIgnoringDuplicates(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, records []*models.FindingRecord) error {
+			s.Require().Len(records, 3)
+			for _, record := range records {
+				s.Require().Equal(int64(2), record.RepositoryID)
+				s.Require().Equal(scanID, record.FirstScanID)
+				s.Require().Equal(models.FindingStateOpen, record.State)
+			}
+			return nil
+		},
+	)
+	s.findingRepo.EXPECT().ListByFingerprints(gomock.Any(), int64(2), fingerprints).Return(trackedFindings, nil)
+	s.findingRepo.EXPECT().MarkSeen(gomock.Any(), scanID, []int64{10, 11, 12}).Return(nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.Scan, statuses []string, params map[string]interface{}) (bool, error) {
+			storedFindings, err := (&models.Scan{Findings: params["findings"].([]byte)}).ParseFindings()
+			s.Require().NoError(err)
+			s.Require().Len(storedFindings, 4)
+			s.Require().Equal(int64(10), storedFindings[0].ID)
+			s.Require().Equal(models.FindingStateFalsePositive, storedFindings[0].State)
+			s.Require().Equal(models.FindingStateOpen, storedFindings[1].State)
+			s.Require().True(storedFindings[1].Baselined)
+			s.Require().Zero(storedFindings[2].ID)
+			// a resolved finding that comes back is open again
+			s.Require().Equal(models.FindingStateOpen, storedFindings[3].State)
+			// neither the suppressed nor the baselined finding fails
+			s.Require().Equal(2, params["failing_findings"])
+			return true, nil
+		},
+	)
+	s.repo.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(repos.IRepo) error) error {
+			return fn(s.repo)
+		},
+	)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+	s.repo.EXPECT().Finding().Return(s.findingRepo).Times(3)
+
+	err = s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
+
+func (s *findingSuite) TestHandleResultMessageRollsBackFindingsOfCancelledScan() {
+	scanID := int64(3)
+	timeNow := time.Now()
+	rawFindings, err := json.Marshal([]models.Finding{
+		{RuleID: "G101", Location: models.Location{Path: "config.py"}, Fingerprint: "known"},
+	})
+	s.Require().NoError(err)
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusSuccess,
+		Findings:   rawFindings,
+		FinishedAt: &timeNow,
+	}
+
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).
+		Return(&models.Scan{ID: scanID, RepositoryID: 2, Status: models.ScanStatusInProgress}, nil)
+	s.baselineRepo.EXPECT().GetByRepositoryID(gomock.Any(), int64(2)).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryBaseline().Return(s.baselineRepo)
+	s.findingRepo.EXPECT().CreateIgnoringDuplicates(gomock.Any(), gomock.Any()).Return(nil)
+	s.findingRepo.EXPECT().ListByFingerprints(gomock.Any(), int64(2), []string{"known"}).
+		Return([]*models.FindingRecord{{ID: 10, Fingerprint: "known", State: models.FindingStateOpen}}, nil)
+	s.findingRepo.EXPECT().MarkSeen(gomock.Any(), scanID, []int64{10}).Return(nil)
+	// the scan was cancelled meanwhile
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+	s.repo.EXPECT().WithTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(repos.IRepo) error) error {
+			err := fn(s.repo)
+			// an error rolls the tracked findings back
+			s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+			return err
+		},
+	)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+	s.repo.EXPECT().Finding().Return(s.findingRepo).Times(3)
+
+	err = s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
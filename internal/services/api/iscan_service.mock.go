@@ -6,10 +6,12 @@ package api
 
 import (
 	context "context"
+	json "encoding/json"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/vumanhcuongit/scan/pkg/models"
+	sarif "github.com/vumanhcuongit/scan/pkg/sarif"
 )
 
 // MockIScanService is a mock of IScanService interface.
@@ -35,6 +37,21 @@ func (m *MockIScanService) EXPECT() *MockIScanServiceMockRecorder {
 	return m.recorder
 }
 
+// CancelScan mocks base method.
+func (m *MockIScanService) CancelScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScan", ctx, scanID)
+	ret0, _ := ret[0].(*models.Scan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScan indicates an expected call of CancelScan.
+func (mr *MockIScanServiceMockRecorder) CancelScan(ctx, scanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScan", reflect.TypeOf((*MockIScanService)(nil).CancelScan), ctx, scanID)
+}
+
 // CreateRepository mocks base method.
 func (m *MockIScanService) CreateRepository(ctx context.Context, request *CreateRepositoryRequest) (*models.Repository, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +81,34 @@ func (mr *MockIScanServiceMockRecorder) DeleteRepository(ctx, repositoryID inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRepository", reflect.TypeOf((*MockIScanService)(nil).DeleteRepository), ctx, repositoryID)
 }
 
+// DeleteRepositoryBaseline mocks base method.
+func (m *MockIScanService) DeleteRepositoryBaseline(ctx context.Context, repositoryID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRepositoryBaseline", ctx, repositoryID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRepositoryBaseline indicates an expected call of DeleteRepositoryBaseline.
+func (mr *MockIScanServiceMockRecorder) DeleteRepositoryBaseline(ctx, repositoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRepositoryBaseline", reflect.TypeOf((*MockIScanService)(nil).DeleteRepositoryBaseline), ctx, repositoryID)
+}
+
+// DeleteRepositoryCredential mocks base method.
+func (m *MockIScanService) DeleteRepositoryCredential(ctx context.Context, repositoryID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRepositoryCredential", ctx, repositoryID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRepositoryCredential indicates an expected call of DeleteRepositoryCredential.
+func (mr *MockIScanServiceMockRecorder) DeleteRepositoryCredential(ctx, repositoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRepositoryCredential", reflect.TypeOf((*MockIScanService)(nil).DeleteRepositoryCredential), ctx, repositoryID)
+}
+
 // GetRepository mocks base method.
 func (m *MockIScanService) GetRepository(ctx context.Context, repositoryID int64) (*models.Repository, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +124,81 @@ func (mr *MockIScanServiceMockRecorder) GetRepository(ctx, repositoryID interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepository", reflect.TypeOf((*MockIScanService)(nil).GetRepository), ctx, repositoryID)
 }
 
+// GetRepositoryBaseline mocks base method.
+func (m *MockIScanService) GetRepositoryBaseline(ctx context.Context, repositoryID int64) (*models.RepositoryBaseline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositoryBaseline", ctx, repositoryID)
+	ret0, _ := ret[0].(*models.RepositoryBaseline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRepositoryBaseline indicates an expected call of GetRepositoryBaseline.
+func (mr *MockIScanServiceMockRecorder) GetRepositoryBaseline(ctx, repositoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositoryBaseline", reflect.TypeOf((*MockIScanService)(nil).GetRepositoryBaseline), ctx, repositoryID)
+}
+
+// GetRepositoryCredential mocks base method.
+func (m *MockIScanService) GetRepositoryCredential(ctx context.Context, repositoryID int64) (*models.RepositoryCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRepositoryCredential", ctx, repositoryID)
+	ret0, _ := ret[0].(*models.RepositoryCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRepositoryCredential indicates an expected call of GetRepositoryCredential.
+func (mr *MockIScanServiceMockRecorder) GetRepositoryCredential(ctx, repositoryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRepositoryCredential", reflect.TypeOf((*MockIScanService)(nil).GetRepositoryCredential), ctx, repositoryID)
+}
+
+// GetScan mocks base method.
+func (m *MockIScanService) GetScan(ctx context.Context, scanID int64, request *GetScanRequest) (map[string]json.RawMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScan", ctx, scanID, request)
+	ret0, _ := ret[0].(map[string]json.RawMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScan indicates an expected call of GetScan.
+func (mr *MockIScanServiceMockRecorder) GetScan(ctx, scanID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScan", reflect.TypeOf((*MockIScanService)(nil).GetScan), ctx, scanID, request)
+}
+
+// GetScanDiff mocks base method.
+func (m *MockIScanService) GetScanDiff(ctx context.Context, scanID int64, request *GetScanDiffRequest) (*models.ScanDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScanDiff", ctx, scanID, request)
+	ret0, _ := ret[0].(*models.ScanDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScanDiff indicates an expected call of GetScanDiff.
+func (mr *MockIScanServiceMockRecorder) GetScanDiff(ctx, scanID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScanDiff", reflect.TypeOf((*MockIScanService)(nil).GetScanDiff), ctx, scanID, request)
+}
+
+// GetScanReport mocks base method.
+func (m *MockIScanService) GetScanReport(ctx context.Context, scanID int64, request *GetScanReportRequest) (*sarif.Log, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScanReport", ctx, scanID, request)
+	ret0, _ := ret[0].(*sarif.Log)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScanReport indicates an expected call of GetScanReport.
+func (mr *MockIScanServiceMockRecorder) GetScanReport(ctx, scanID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScanReport", reflect.TypeOf((*MockIScanService)(nil).GetScanReport), ctx, scanID, request)
+}
+
 // HandleResultMessage mocks base method.
 func (m *MockIScanService) HandleResultMessage(ctx context.Context, result *models.ScanResultMessage) error {
 	m.ctrl.T.Helper()
@@ -93,6 +213,21 @@ func (mr *MockIScanServiceMockRecorder) HandleResultMessage(ctx, result interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleResultMessage", reflect.TypeOf((*MockIScanService)(nil).HandleResultMessage), ctx, result)
 }
 
+// ListFindings mocks base method.
+func (m *MockIScanService) ListFindings(ctx context.Context, request *ListFindingsRequest) ([]*models.FindingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFindings", ctx, request)
+	ret0, _ := ret[0].([]*models.FindingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFindings indicates an expected call of ListFindings.
+func (mr *MockIScanServiceMockRecorder) ListFindings(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFindings", reflect.TypeOf((*MockIScanService)(nil).ListFindings), ctx, request)
+}
+
 // ListRepositories mocks base method.
 func (m *MockIScanService) ListRepositories(ctx context.Context, request *ListRepositoriesRequest) ([]*models.Repository, error) {
 	m.ctrl.T.Helper()
@@ -123,6 +258,81 @@ func (mr *MockIScanServiceMockRecorder) ListScans(ctx, request interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScans", reflect.TypeOf((*MockIScanService)(nil).ListScans), ctx, request)
 }
 
+// ListSuppressedFindings mocks base method.
+func (m *MockIScanService) ListSuppressedFindings(ctx context.Context, scanID int64) ([]models.Finding, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSuppressedFindings", ctx, scanID)
+	ret0, _ := ret[0].([]models.Finding)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSuppressedFindings indicates an expected call of ListSuppressedFindings.
+func (mr *MockIScanServiceMockRecorder) ListSuppressedFindings(ctx, scanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSuppressedFindings", reflect.TypeOf((*MockIScanService)(nil).ListSuppressedFindings), ctx, scanID)
+}
+
+// RerunScan mocks base method.
+func (m *MockIScanService) RerunScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RerunScan", ctx, scanID)
+	ret0, _ := ret[0].(*models.Scan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RerunScan indicates an expected call of RerunScan.
+func (mr *MockIScanServiceMockRecorder) RerunScan(ctx, scanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RerunScan", reflect.TypeOf((*MockIScanService)(nil).RerunScan), ctx, scanID)
+}
+
+// RetryScan mocks base method.
+func (m *MockIScanService) RetryScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryScan", ctx, scanID)
+	ret0, _ := ret[0].(*models.Scan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetryScan indicates an expected call of RetryScan.
+func (mr *MockIScanServiceMockRecorder) RetryScan(ctx, scanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryScan", reflect.TypeOf((*MockIScanService)(nil).RetryScan), ctx, scanID)
+}
+
+// SetRepositoryBaseline mocks base method.
+func (m *MockIScanService) SetRepositoryBaseline(ctx context.Context, repositoryID int64, request *SetRepositoryBaselineRequest) (*models.RepositoryBaseline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRepositoryBaseline", ctx, repositoryID, request)
+	ret0, _ := ret[0].(*models.RepositoryBaseline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRepositoryBaseline indicates an expected call of SetRepositoryBaseline.
+func (mr *MockIScanServiceMockRecorder) SetRepositoryBaseline(ctx, repositoryID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRepositoryBaseline", reflect.TypeOf((*MockIScanService)(nil).SetRepositoryBaseline), ctx, repositoryID, request)
+}
+
+// SetRepositoryCredential mocks base method.
+func (m *MockIScanService) SetRepositoryCredential(ctx context.Context, repositoryID int64, request *SetRepositoryCredentialRequest) (*models.RepositoryCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRepositoryCredential", ctx, repositoryID, request)
+	ret0, _ := ret[0].(*models.RepositoryCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRepositoryCredential indicates an expected call of SetRepositoryCredential.
+func (mr *MockIScanServiceMockRecorder) SetRepositoryCredential(ctx, repositoryID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRepositoryCredential", reflect.TypeOf((*MockIScanService)(nil).SetRepositoryCredential), ctx, repositoryID, request)
+}
+
 // Start mocks base method.
 func (m *MockIScanService) Start(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -152,6 +362,21 @@ func (mr *MockIScanServiceMockRecorder) TriggerScan(ctx, request interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerScan", reflect.TypeOf((*MockIScanService)(nil).TriggerScan), ctx, request)
 }
 
+// UpdateFinding mocks base method.
+func (m *MockIScanService) UpdateFinding(ctx context.Context, findingID int64, request *UpdateFindingRequest) (*models.FindingRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFinding", ctx, findingID, request)
+	ret0, _ := ret[0].(*models.FindingRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateFinding indicates an expected call of UpdateFinding.
+func (mr *MockIScanServiceMockRecorder) UpdateFinding(ctx, findingID, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFinding", reflect.TypeOf((*MockIScanService)(nil).UpdateFinding), ctx, findingID, request)
+}
+
 // UpdateRepository mocks base method.
 func (m *MockIScanService) UpdateRepository(ctx context.Context, repositoryID int64, request *UpdateRepositoryRequest) (*models.Repository, error) {
 	m.ctrl.T.Helper()
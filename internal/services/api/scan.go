@@ -3,22 +3,42 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/vumanhcuongit/scan/pkg/models"
+	"github.com/vumanhcuongit/scan/pkg/sarif"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	ReportFormatSARIF = "sarif"
+
+	maxRefLength = 255
 )
 
 type TriggerScanRequest struct {
-	RepositoryID int64 `json:"repository_id" binding:"required"`
+	RepositoryID int64  `json:"repository_id" binding:"required"`
+	Ref          string `json:"ref"`     // branch, tag or commit SHA, the default branch if empty
+	History      bool   `json:"history"` // also report secrets that were committed then deleted
 }
 
 type UpdateScanRequest struct {
-	Status     string     `json:"status"`
-	Findings   []byte     `json:"findings"`
-	QueuedAt   *time.Time `json:"queued_at"`
-	ScanningAt *time.Time `json:"scanning_at"`
-	FinishedAt *time.Time `json:"finished_at"`
+	Status          string     `json:"status"`
+	ErrorCode       string     `json:"error_code"`
+	ErrorMessage    string     `json:"error_message"`
+	Findings        []byte     `json:"findings"`
+	CommitSHA       string     `json:"commit_sha"`
+	Stats           []byte     `json:"stats"`
+	FailingFindings *int       `json:"failing_findings"`
+	QueuedAt        *time.Time `json:"queued_at"`
+	ScanningAt      *time.Time `json:"scanning_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
 }
 
 type ListScansRequest struct {
@@ -27,6 +47,18 @@ type ListScansRequest struct {
 	Page         int    `json:"page" form:"page"`
 }
 
+type GetScanReportRequest struct {
+	Format string `json:"format" form:"format"`
+}
+
+type GetScanRequest struct {
+	Fields string `json:"fields" form:"fields"` // comma separated top level fields to return, e.g. id,status,summary
+}
+
+type GetScanDiffRequest struct {
+	Base *int64 `json:"base" form:"base"` // the previous successful scan of the repository if empty
+}
+
 func (s *ScanService) ListScans(ctx context.Context, request *ListScansRequest) ([]*models.Scan, error) {
 	log := zap.S()
 	log.Infof("starting to list scans with request %+v", request)
@@ -48,6 +80,65 @@ func (s *ScanService) TriggerScan(ctx context.Context, request *TriggerScanReque
 	log := zap.S()
 	log.Infof("starting to trigger a scan with request %+v", request)
 
+	if request.Ref != "" && !isValidRef(request.Ref) {
+		log.Warnf("invalid ref %s", request.Ref)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid ref: %s", request.Ref)
+	}
+
+	return s.triggerScan(ctx, request, nil)
+}
+
+// RetryScan scans again what a failed scan was to scan, the new scan keeps the lineage
+// through its parent scan.
+func (s *ScanService) RetryScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	log := zap.S()
+	log.Infof("starting to retry scan %d", scanID)
+
+	scan, err := s.getScan(ctx, scanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return nil, err
+	}
+	if scan.Status != models.ScanStatusFailure {
+		log.Warnf("scan %d cannot be retried", scanID)
+		return nil, status.Errorf(codes.FailedPrecondition, "scan %d cannot be retried, its status is %s", scanID, scan.Status)
+	}
+
+	return s.rescan(ctx, scan)
+}
+
+// RerunScan scans again what a finished scan was to scan, whatever its outcome, e.g.
+// once the rules changed. Like a retry, the new scan keeps the lineage through its parent scan.
+func (s *ScanService) RerunScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	log := zap.S()
+	log.Infof("starting to rerun scan %d", scanID)
+
+	scan, err := s.getScan(ctx, scanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return nil, err
+	}
+	if !models.IsFinishedScanStatus(scan.Status) {
+		log.Warnf("scan %d cannot be rerun", scanID)
+		return nil, status.Errorf(codes.FailedPrecondition, "scan %d cannot be rerun before it finishes, its status is %s", scanID, scan.Status)
+	}
+
+	return s.rescan(ctx, scan)
+}
+
+// rescan triggers a scan of the ref and with the options of a scan, which is its parent scan.
+func (s *ScanService) rescan(ctx context.Context, scan *models.Scan) (*models.Scan, error) {
+	request := &TriggerScanRequest{
+		RepositoryID: scan.RepositoryID,
+		Ref:          scan.Ref,
+		History:      scan.History,
+	}
+	return s.triggerScan(ctx, request, &scan.ID)
+}
+
+func (s *ScanService) triggerScan(ctx context.Context, request *TriggerScanRequest, parentScanID *int64) (*models.Scan, error) {
+	log := zap.S()
+
 	// first check if this repository exists or not
 	repository, err := s.GetRepository(ctx, request.RepositoryID)
 	if err != nil {
@@ -55,19 +146,197 @@ func (s *ScanService) TriggerScan(ctx context.Context, request *TriggerScanReque
 		return nil, err
 	}
 
-	scan, err := s.createScan(ctx, repository)
+	credential, err := s.getRepositoryCredential(ctx, repository.ID)
+	if err != nil {
+		log.Warnf("failed to get credential, err: %+v", err)
+		return nil, err
+	}
+
+	scan, err := s.createScan(ctx, repository, request, parentScanID)
 	if err != nil {
 		log.Warnf("failed to create scan, err: %+v", err)
 		return nil, err
 	}
 
-	err = s.produceTriggerScanMessage(ctx, scan, repository)
+	// the scan is queued before the message is written, the worker may report on it right away
+	updatedScan, err := s.updateQueuedScan(ctx, scan)
+	if err != nil {
+		log.Warnf("failed to update scan, err: %+v", err)
+		return nil, err
+	}
+
+	err = s.produceTriggerScanMessage(ctx, updatedScan, repository, credential)
 	if err != nil {
 		log.Warnf("failed to write message to queue, err: %+v", err)
+		s.unqueueScan(ctx, updatedScan)
 		return nil, err
 	}
 
-	updatedScan, err := s.updateQueuedScan(ctx, scan)
+	return updatedScan, nil
+}
+
+// GetScan returns a scan with the summary of its findings, restricted to the requested
+// fields so that callers can skip the findings which may be large.
+func (s *ScanService) GetScan(ctx context.Context, scanID int64, request *GetScanRequest) (map[string]json.RawMessage, error) {
+	log := zap.S()
+	log.Infof("starting to get scan %d with request %+v", scanID, request)
+
+	scan, err := s.getScan(ctx, scanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return nil, err
+	}
+
+	summary, err := models.NewScanSummary(scan)
+	if err != nil {
+		log.Warnf("failed to summarize findings, err: %+v", err)
+		return nil, err
+	}
+
+	fields := []string{}
+	for _, field := range strings.Split(request.Fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	detail, err := (&models.ScanDetail{Scan: scan, Summary: summary}).Select(fields)
+	if err != nil {
+		log.Warnf("failed to select fields, err: %+v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return detail, nil
+}
+
+// GetScanReport exports the findings of a successful scan, SARIF is the only supported format for now.
+func (s *ScanService) GetScanReport(ctx context.Context, scanID int64, request *GetScanReportRequest) (*sarif.Log, error) {
+	log := zap.S()
+	log.Infof("starting to get report of scan %d with request %+v", scanID, request)
+
+	if request.Format != ReportFormatSARIF {
+		log.Warnf("unsupported report format %s", request.Format)
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported report format: %s", request.Format)
+	}
+
+	// the findings of an unfinished or failed scan would read as a clean report
+	scan, err := s.getSuccessfulScan(ctx, scanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return nil, err
+	}
+
+	findings, err := scan.ParseFindings()
+	if err != nil {
+		log.Warnf("failed to parse findings, err: %+v", err)
+		return nil, err
+	}
+
+	return sarif.NewLog(scan, findings), nil
+}
+
+// ListSuppressedFindings returns the findings of a scan that a scan:ignore comment suppressed, for audit.
+func (s *ScanService) ListSuppressedFindings(ctx context.Context, scanID int64) ([]models.Finding, error) {
+	log := zap.S()
+	log.Infof("starting to list suppressed findings of scan %d", scanID)
+
+	scan, err := s.getScan(ctx, scanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return nil, err
+	}
+
+	findings, err := scan.ParseFindings()
+	if err != nil {
+		log.Warnf("failed to parse findings, err: %+v", err)
+		return nil, err
+	}
+
+	suppressed := []models.Finding{}
+	for _, finding := range findings {
+		if finding.Suppressed {
+			suppressed = append(suppressed, finding)
+		}
+	}
+
+	return suppressed, nil
+}
+
+// GetScanDiff tells which findings of a scan are new, fixed or unchanged since its base scan.
+func (s *ScanService) GetScanDiff(ctx context.Context, scanID int64, request *GetScanDiffRequest) (*models.ScanDiff, error) {
+	log := zap.S()
+	log.Infof("starting to get diff of scan %d with request %+v", scanID, request)
+
+	scan, err := s.getSuccessfulScan(ctx, scanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return nil, err
+	}
+
+	var base *models.Scan
+	if request.Base != nil {
+		base, err = s.getSuccessfulScan(ctx, *request.Base)
+		if err != nil {
+			log.Warnf("failed to get base scan, err: %+v", err)
+			return nil, err
+		}
+		if base.RepositoryID != scan.RepositoryID {
+			log.Warnf("base scan %d is of another repository", base.ID)
+			return nil, status.Errorf(codes.InvalidArgument, "base scan %d is not a scan of repository %d", base.ID, scan.RepositoryID)
+		}
+	} else {
+		// the scans of another ref or mode differ by more than the changes of the code
+		successStatus := models.ScanStatusSuccess
+		previousScans, err := s.repo.Scan().List(ctx, 1, 1, &models.ScanFilter{
+			RepositoryID: &scan.RepositoryID,
+			Status:       &successStatus,
+			Ref:          &scan.Ref,
+			History:      &scan.History,
+			BeforeID:     &scan.ID,
+		})
+		if err != nil {
+			log.Warnf("failed to get previous scan, err: %+v", err)
+			return nil, err
+		}
+		if len(previousScans) > 0 {
+			base = previousScans[0]
+		}
+	}
+
+	diff, err := models.NewScanDiff(scan, base)
+	if err != nil {
+		log.Warnf("failed to diff findings, err: %+v", err)
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// CancelScan stops a scan that has not finished. A scan the execution service may have
+// picked up is cancelled there too, the results it still reports are ignored.
+func (s *ScanService) CancelScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	log := zap.S()
+	log.Infof("starting to cancel scan %d", scanID)
+
+	scan, err := s.getScan(ctx, scanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return nil, err
+	}
+
+	if !models.CanTransitionScan(scan.Status, models.ScanStatusCancelled) {
+		log.Warnf("scan %d has already finished", scanID)
+		return nil, status.Errorf(codes.FailedPrecondition, "scan %d has already finished, its status is %s", scanID, scan.Status)
+	}
+	if scan.Status != models.ScanStatusPending {
+		err = s.produceCancelScanMessage(ctx, scan.ID)
+		if err != nil {
+			log.Warnf("failed to write message to queue, err: %+v", err)
+			return nil, err
+		}
+	}
+
+	timeNow := time.Now()
+	updatedScan, err := s.UpdateScan(ctx, scan, &UpdateScanRequest{Status: models.ScanStatusCancelled, FinishedAt: &timeNow})
 	if err != nil {
 		log.Warnf("failed to update scan, err: %+v", err)
 		return nil, err
@@ -76,19 +345,82 @@ func (s *ScanService) TriggerScan(ctx context.Context, request *TriggerScanReque
 	return updatedScan, nil
 }
 
+func (s *ScanService) getScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	scan, err := s.repo.Scan().GetByID(ctx, scanID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.NotFound, "scan %d not found", scanID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return scan, nil
+}
+
+// getSuccessfulScan returns a scan whose findings are complete.
+func (s *ScanService) getSuccessfulScan(ctx context.Context, scanID int64) (*models.Scan, error) {
+	scan, err := s.getScan(ctx, scanID)
+	if err != nil {
+		return nil, err
+	}
+	if scan.Status != models.ScanStatusSuccess {
+		return nil, status.Errorf(codes.FailedPrecondition, "scan %d has not succeeded, its status is %s", scanID, scan.Status)
+	}
+
+	return scan, nil
+}
+
 func (s *ScanService) UpdateScan(ctx context.Context, scan *models.Scan, request *UpdateScanRequest) (*models.Scan, error) {
 	log := zap.S()
 	log.Infof("starting to update repository with request %+v", request)
 
 	changesets := map[string]interface{}{}
+	previousStatus := scan.Status
+	staleFailure := scan.IsStaleFailure()
 	if request.Status != "" {
+		if !models.IsValidScanStatus(request.Status) {
+			log.Warnf("unknown scan status %s", request.Status)
+			return nil, status.Errorf(codes.InvalidArgument, "unknown scan status %s", request.Status)
+		}
+		if !scan.CanMoveTo(request.Status) {
+			log.Warnf("scan %d cannot move from %s to %s", scan.ID, scan.Status, request.Status)
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"scan %d cannot move from %s to %s", scan.ID, scan.Status, request.Status)
+		}
 		changesets["status"] = request.Status
 		scan.Status = request.Status
+		if staleFailure {
+			// the outcome of the worker replaces the error of the stale checker
+			changesets["error_code"] = request.ErrorCode
+			changesets["error_message"] = request.ErrorMessage
+			scan.ErrorCode = request.ErrorCode
+			scan.ErrorMessage = request.ErrorMessage
+		}
+	}
+	if request.ErrorCode != "" {
+		changesets["error_code"] = request.ErrorCode
+		scan.ErrorCode = request.ErrorCode
+	}
+	if request.ErrorMessage != "" {
+		changesets["error_message"] = request.ErrorMessage
+		scan.ErrorMessage = request.ErrorMessage
 	}
 	if request.Findings != nil {
 		changesets["findings"] = request.Findings
 		scan.Findings = request.Findings
 	}
+	if request.CommitSHA != "" {
+		changesets["commit_sha"] = request.CommitSHA
+		scan.CommitSHA = request.CommitSHA
+	}
+	if request.Stats != nil {
+		changesets["stats"] = request.Stats
+		scan.Stats = request.Stats
+	}
+	if request.FailingFindings != nil {
+		changesets["failing_findings"] = *request.FailingFindings
+		scan.FailingFindings = *request.FailingFindings
+	}
 	if request.QueuedAt != nil {
 		changesets["queued_at"] = request.QueuedAt
 		scan.QueuedAt = request.QueuedAt
@@ -102,22 +434,51 @@ func (s *ScanService) UpdateScan(ctx context.Context, scan *models.Scan, request
 		scan.FinishedAt = request.FinishedAt
 	}
 
-	err := s.repo.Scan().UpdateWithMap(ctx, scan, changesets)
+	if request.Status == "" {
+		err := s.repo.Scan().UpdateWithMap(ctx, scan, changesets)
+		if err != nil {
+			log.Warnf("failed to update scan, err: +%v", err)
+			return nil, err
+		}
+		return scan, nil
+	}
+
+	// the status may have moved since the scan was read, the update is then refused
+	var updated bool
+	var err error
+	if staleFailure {
+		updated, err = s.repo.Scan().UpdateStaleFailureWithMap(ctx, scan, changesets)
+	} else {
+		updated, err = s.repo.Scan().UpdateWithMapFromStatuses(ctx, scan, models.PreviousScanStatuses(request.Status), changesets)
+	}
 	if err != nil {
 		log.Warnf("failed to update scan, err: +%v", err)
 		return nil, err
 	}
+	if !updated {
+		log.Warnf("scan %d moved from %s concurrently", scan.ID, previousStatus)
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"scan %d moved from %s before it could move to %s", scan.ID, previousStatus, request.Status)
+	}
 
 	return scan, nil
 }
 
-func (s *ScanService) createScan(ctx context.Context, repository *models.Repository) (*models.Scan, error) {
+func (s *ScanService) createScan(
+	ctx context.Context,
+	repository *models.Repository,
+	request *TriggerScanRequest,
+	parentScanID *int64,
+) (*models.Scan, error) {
 	log := zap.S()
 	record, err := models.NewScan(repository)
 	if err != nil {
 		log.Warnf("failed to init scan, err: %+v", err)
 		return nil, err
 	}
+	record.Ref = request.Ref
+	record.History = request.History
+	record.ParentScanID = parentScanID
 
 	scan, err := s.repo.Scan().Create(ctx, record)
 	if err != nil {
@@ -128,14 +489,27 @@ func (s *ScanService) createScan(ctx context.Context, repository *models.Reposit
 	return scan, nil
 }
 
-func (s *ScanService) produceTriggerScanMessage(ctx context.Context, scan *models.Scan, repository *models.Repository) error {
+func (s *ScanService) produceTriggerScanMessage(
+	ctx context.Context,
+	scan *models.Scan,
+	repository *models.Repository,
+	credential *models.RepositoryCredential,
+) error {
 	log := zap.S()
 
-	message, err := json.Marshal(models.ScanRequestMessage{
-		ScanID:     scan.ID,
-		Owner:      repository.Owner,
-		Repository: repository.Name,
-	})
+	requestMessage := models.ScanRequestMessage{
+		ScanID:        scan.ID,
+		Owner:         repository.Owner,
+		Repository:    repository.Name,
+		RepositoryURL: repository.RepositoryURL,
+		Ref:           scan.Ref,
+		History:       scan.History,
+	}
+	if credential != nil {
+		// the secret stays encrypted in the queue, only the worker fetching the repository decrypts it
+		requestMessage.Credential = credential.Sealed()
+	}
+	message, err := json.Marshal(requestMessage)
 	if err != nil {
 		log.Warnf("failed to marshal message, err: %+v", err)
 		return err
@@ -150,6 +524,23 @@ func (s *ScanService) produceTriggerScanMessage(ctx context.Context, scan *model
 	return nil
 }
 
+func (s *ScanService) produceCancelScanMessage(ctx context.Context, scanID int64) error {
+	log := zap.S()
+	message, err := json.Marshal(models.ScanCancelMessage{ScanID: scanID})
+	if err != nil {
+		log.Warnf("failed to marshal message, err: %+v", err)
+		return err
+	}
+
+	err = s.cancelWriter.WriteMessage(ctx, message)
+	if err != nil {
+		log.Warnf("failed to write message to queue, err: %+v", err)
+		return err
+	}
+
+	return nil
+}
+
 func (s *ScanService) updateQueuedScan(ctx context.Context, scan *models.Scan) (*models.Scan, error) {
 	log := zap.S()
 	timeNow := time.Now()
@@ -163,10 +554,41 @@ func (s *ScanService) updateQueuedScan(ctx context.Context, scan *models.Scan) (
 	return updatedScan, nil
 }
 
+// unqueueScan moves a scan whose request message could not be written back to Pending.
+// No worker knows about the scan, so the move bypasses the transitions of the statuses.
+func (s *ScanService) unqueueScan(ctx context.Context, scan *models.Scan) {
+	log := zap.S()
+	changesets := map[string]interface{}{
+		"status":    models.ScanStatusPending,
+		"queued_at": nil,
+	}
+	_, err := s.repo.Scan().UpdateWithMapFromStatuses(ctx, scan, []string{models.ScanStatusQueued}, changesets)
+	if err != nil {
+		log.Warnf("failed to move scan %d back to pending, err: %+v", scan.ID, err)
+		return
+	}
+	scan.Status = models.ScanStatusPending
+	scan.QueuedAt = nil
+}
+
 // HandleResultMessage handles result returned from workers
 func (s *ScanService) HandleResultMessage(ctx context.Context, result *models.ScanResultMessage) error {
 	log := zap.S()
-	scan := &models.Scan{ID: result.ScanID}
+	scan, err := s.repo.Scan().GetByID(ctx, result.ScanID)
+	if err != nil {
+		log.Warnf("failed to get scan, err: %+v", err)
+		return err
+	}
+	if !scan.CanMoveTo(result.ScanStatus) {
+		// a redelivered or late message, e.g. the result of a cancelled scan
+		log.Infof("ignored %s result of scan %d, its status is %s", result.ScanStatus, scan.ID, scan.Status)
+		if scan.Status == models.ScanStatusCancelled && result.ScanStatus == models.ScanStatusInProgress {
+			// the worker picked the scan up before the cancellation reached it
+			return s.produceCancelScanMessage(ctx, scan.ID)
+		}
+		return nil
+	}
+
 	updateScanRequest := &UpdateScanRequest{
 		Status: result.ScanStatus,
 	}
@@ -175,15 +597,34 @@ func (s *ScanService) HandleResultMessage(ctx context.Context, result *models.Sc
 		updateScanRequest.ScanningAt = result.ScanningAt
 	case models.ScanStatusSuccess:
 		updateScanRequest.FinishedAt = result.FinishedAt
-		updateScanRequest.Findings = result.Findings
+		updateScanRequest.CommitSHA = result.CommitSHA
+		if result.Stats != nil {
+			stats, err := json.Marshal(result.Stats)
+			if err != nil {
+				log.Warnf("failed to marshal stats, err: %+v", err)
+				return err
+			}
+			updateScanRequest.Stats = stats
+		}
 	case models.ScanStatusFailure:
 		updateScanRequest.FinishedAt = result.FinishedAt
+		updateScanRequest.ErrorCode = result.ErrorCode
+		updateScanRequest.ErrorMessage = result.ErrorMessage
 	default:
 		log.Warnf("unsupported status")
 		return nil
 	}
 
-	updatedScan, err := s.UpdateScan(ctx, scan, updateScanRequest)
+	var updatedScan *models.Scan
+	if result.ScanStatus == models.ScanStatusSuccess && result.Findings != nil {
+		updatedScan, err = s.updateScanWithFindings(ctx, scan, updateScanRequest, result.Findings)
+	} else {
+		updatedScan, err = s.UpdateScan(ctx, scan, updateScanRequest)
+	}
+	if status.Code(err) == codes.FailedPrecondition {
+		log.Infof("ignored %s result of scan %d, err: %+v", result.ScanStatus, scan.ID, err)
+		return nil
+	}
 	if err != nil {
 		log.Warnf("failed to update scan, err: %+v", err)
 		return err
@@ -192,3 +633,22 @@ func (s *ScanService) HandleResultMessage(ctx context.Context, result *models.Sc
 
 	return nil
 }
+
+// isValidRef rejects refs git itself would refuse, see git-check-ref-format(1),
+// so that malformed input fails on trigger instead of in the worker.
+func isValidRef(ref string) bool {
+	if len(ref) > maxRefLength ||
+		strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "/") ||
+		strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") || strings.HasSuffix(ref, ".lock") ||
+		strings.Contains(ref, "..") || strings.Contains(ref, "//") || strings.Contains(ref, "@{") {
+		return false
+	}
+
+	for _, r := range ref {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(`~^:?*[\`, r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,16 +2,22 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/vumanhcuongit/scan/internal/repos"
 	"github.com/vumanhcuongit/scan/pkg/kafka"
 	"github.com/vumanhcuongit/scan/pkg/models"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 )
 
 type scanSuite struct {
@@ -21,7 +27,9 @@ type scanSuite struct {
 	repo           *repos.MockIRepo
 	scanRepo       *repos.MockIScanRepo
 	repositoryRepo *repos.MockIRepositoryRepo
+	credentialRepo *repos.MockIRepositoryCredentialRepo
 	kafkaWriter    *kafka.MockIWriter
+	cancelWriter   *kafka.MockIWriter
 	scanService    *ScanService
 }
 
@@ -43,8 +51,10 @@ func (s *scanSuite) SetupTest() {
 	s.repo = repos.NewMockIRepo(s.mockCtrl)
 	s.scanRepo = repos.NewMockIScanRepo(s.mockCtrl)
 	s.kafkaWriter = kafka.NewMockIWriter(s.mockCtrl)
-	s.scanService = &ScanService{repo: s.repo, kafkaWriter: s.kafkaWriter}
+	s.cancelWriter = kafka.NewMockIWriter(s.mockCtrl)
+	s.scanService = &ScanService{repo: s.repo, kafkaWriter: s.kafkaWriter, cancelWriter: s.cancelWriter}
 	s.repositoryRepo = repos.NewMockIRepositoryRepo(s.mockCtrl)
+	s.credentialRepo = repos.NewMockIRepositoryCredentialRepo(s.mockCtrl)
 }
 
 func (s *scanSuite) TearDownTest() {
@@ -99,7 +109,7 @@ func (s *scanSuite) TestListScansWithError() {
 }
 
 func (s *scanSuite) TestUpdateScan() {
-	scan := &models.Scan{}
+	scan := &models.Scan{Status: models.ScanStatusPending}
 	timeNow := time.Now()
 	request := &UpdateScanRequest{
 		Status:     models.ScanStatusQueued,
@@ -115,7 +125,7 @@ func (s *scanSuite) TestUpdateScan() {
 		"scanning_at": &timeNow,
 		"finished_at": &timeNow,
 	}
-	s.scanRepo.EXPECT().UpdateWithMap(gomock.Any(), scan, changesets).Return(nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), scan, []string{models.ScanStatusPending}, changesets).Return(true, nil)
 	s.repo.EXPECT().Scan().Return(s.scanRepo)
 
 	_, err := s.scanService.UpdateScan(context.Background(), scan, request)
@@ -123,7 +133,7 @@ func (s *scanSuite) TestUpdateScan() {
 }
 
 func (s *scanSuite) TestUpdateScanWithFailedUpdation() {
-	scan := &models.Scan{}
+	scan := &models.Scan{Status: models.ScanStatusPending}
 	timeNow := time.Now()
 	request := &UpdateScanRequest{
 		Status:     models.ScanStatusQueued,
@@ -139,7 +149,7 @@ func (s *scanSuite) TestUpdateScanWithFailedUpdation() {
 		"scanning_at": &timeNow,
 		"finished_at": &timeNow,
 	}
-	s.scanRepo.EXPECT().UpdateWithMap(gomock.Any(), scan, changesets).Return(errors.New("invalid data"))
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), scan, gomock.Any(), changesets).Return(false, errors.New("invalid data"))
 	s.repo.EXPECT().Scan().Return(s.scanRepo)
 
 	_, err := s.scanService.UpdateScan(context.Background(), scan, request)
@@ -157,9 +167,14 @@ func (s *scanSuite) TestTriggerScan() {
 	}
 
 	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repoID).Return(expectedRepository, nil)
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), repoID).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
 	s.scanRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(expectedScan, nil)
-	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
-	s.scanRepo.EXPECT().UpdateWithMap(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	// the scan is queued before a worker can report on it
+	gomock.InOrder(
+		s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+		s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil),
+	)
 	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
 	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
 
@@ -170,6 +185,91 @@ func (s *scanSuite) TestTriggerScan() {
 	s.Require().Equal(models.ScanStatusQueued, scan.Status)
 }
 
+func (s *scanSuite) TestTriggerScanWithOptions() {
+	repoID := int64(1)
+	repositoryURL := "https://github.com/vumanhcuongit/scan"
+	expectedRepository, _ := models.NewRepository(repositoryURL)
+	expectedRepository.ID = repoID
+	request := &TriggerScanRequest{
+		RepositoryID: repoID,
+		Ref:          "release/v1.2",
+		History:      true,
+	}
+
+	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repoID).Return(expectedRepository, nil)
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), repoID).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
+	s.scanRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.Scan) (*models.Scan, error) {
+			s.Require().Equal(request.Ref, record.Ref)
+			s.Require().True(record.History)
+			return record, nil
+		},
+	)
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, message []byte) error {
+			var requestMessage models.ScanRequestMessage
+			s.Require().NoError(json.Unmarshal(message, &requestMessage))
+			s.Require().Equal(request.Ref, requestMessage.Ref)
+			s.Require().True(requestMessage.History)
+			s.Require().Equal(repositoryURL, requestMessage.RepositoryURL)
+			return nil
+		},
+	)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
+
+	scan, err := s.scanService.TriggerScan(context.Background(), request)
+	s.Require().NoError(err)
+	s.Require().Equal(request.Ref, scan.Ref)
+}
+
+func (s *scanSuite) TestTriggerScanWithCredential() {
+	repoID := int64(1)
+	repositoryURL := "https://gitlab.com/acme/private"
+	expectedRepository, _ := models.NewRepository(repositoryURL)
+	expectedRepository.ID = repoID
+	expectedScan, _ := models.NewScan(expectedRepository)
+	credential := &models.RepositoryCredential{
+		RepositoryID:    repoID,
+		Kind:            models.CredentialKindToken,
+		Username:        "oauth2",
+		EncryptedSecret: "c2VhbGVk",
+	}
+
+	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repoID).Return(expectedRepository, nil)
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), repoID).Return(credential, nil)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
+	s.scanRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(expectedScan, nil)
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, message []byte) error {
+			var requestMessage models.ScanRequestMessage
+			s.Require().NoError(json.Unmarshal(message, &requestMessage))
+			s.Require().Equal(credential.Sealed(), requestMessage.Credential)
+			return nil
+		},
+	)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
+
+	_, err := s.scanService.TriggerScan(context.Background(), &TriggerScanRequest{RepositoryID: repoID})
+	s.Require().NoError(err)
+}
+
+func (s *scanSuite) TestTriggerScanWithInvalidRef() {
+	request := &TriggerScanRequest{
+		RepositoryID: 1,
+		Ref:          "main..dev",
+	}
+
+	scan, err := s.scanService.TriggerScan(context.Background(), request)
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	s.Require().Nil(scan)
+}
+
 func (s *scanSuite) TestTriggerScanWithNotFoundRepo() {
 	repoID := int64(1)
 	request := &TriggerScanRequest{
@@ -195,28 +295,342 @@ func (s *scanSuite) TestTriggerScanWithFailedWritingMessage() {
 	}
 
 	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repoID).Return(expectedRepository, nil)
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), repoID).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
 	s.scanRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(expectedScan, nil)
-	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(errors.New("failed to write message"))
-	s.repo.EXPECT().Scan().Return(s.scanRepo)
+	gomock.InOrder(
+		s.scanRepo.EXPECT().
+			UpdateWithMapFromStatuses(gomock.Any(), expectedScan, []string{models.ScanStatusPending}, gomock.Any()).
+			Return(true, nil),
+		s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(errors.New("failed to write message")),
+		// the scan goes back to pending
+		s.scanRepo.EXPECT().
+			UpdateWithMapFromStatuses(gomock.Any(), expectedScan, []string{models.ScanStatusQueued}, map[string]interface{}{
+				"status":    models.ScanStatusPending,
+				"queued_at": nil,
+			}).
+			Return(true, nil),
+	)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(3)
 	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
 
 	scan, err := s.scanService.TriggerScan(context.Background(), request)
 	s.Require().Error(err)
 	s.Require().Nil(scan)
+	s.Require().Equal(models.ScanStatusPending, expectedScan.Status)
+	s.Require().Nil(expectedScan.QueuedAt)
 }
+func (s *scanSuite) TestGetScanReport() {
+	scanID := int64(1)
+	scan := &models.Scan{
+		ID:            scanID,
+		RepositoryURL: "https://github.com/vumanhcuongit/scan",
+		Findings:      []byte(`[{"type":"sast","ruleId":"G101","location":{"path":"main.go","positions":{"begin":{"line":2}}},"metadata":{"description":"Potential hardcoded credentials","severity":"HIGH"}}]`),
+		Status:        models.ScanStatusSuccess,
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	report, err := s.scanService.GetScanReport(context.Background(), scanID, &GetScanReportRequest{Format: ReportFormatSARIF})
+	s.Require().NoError(err)
+	s.Require().Len(report.Runs, 1)
+	s.Require().Len(report.Runs[0].Tool.Driver.Rules, 1)
+	s.Require().Len(report.Runs[0].Results, 1)
+	s.Require().Equal("G101", report.Runs[0].Results[0].RuleID)
+	s.Require().Equal(2, report.Runs[0].Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+}
+
+func (s *scanSuite) TestGetScanReportWithoutFindings() {
+	scanID := int64(1)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusSuccess}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	report, err := s.scanService.GetScanReport(context.Background(), scanID, &GetScanReportRequest{Format: ReportFormatSARIF})
+	s.Require().NoError(err)
+	s.Require().Empty(report.Runs[0].Results)
+}
+
+func (s *scanSuite) TestGetScanReportWithUnfinishedScan() {
+	scanID := int64(1)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusInProgress}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	report, err := s.scanService.GetScanReport(context.Background(), scanID, &GetScanReportRequest{Format: ReportFormatSARIF})
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	s.Require().Nil(report)
+}
+
+func (s *scanSuite) TestGetScanReportWithUnsupportedFormat() {
+	report, err := s.scanService.GetScanReport(context.Background(), 1, &GetScanReportRequest{Format: "csv"})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	s.Require().Nil(report)
+}
+
+func (s *scanSuite) TestGetScanReportWithNotFoundScan() {
+	scanID := int64(1)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	report, err := s.scanService.GetScanReport(context.Background(), scanID, &GetScanReportRequest{Format: ReportFormatSARIF})
+	s.Require().Error(err)
+	s.Require().Equal(codes.NotFound, status.Code(err))
+	s.Require().Nil(report)
+}
+
+func (s *scanSuite) TestListSuppressedFindings() {
+	scanID := int64(1)
+	scan := &models.Scan{
+		ID: scanID,
+		Findings: []byte(`[{"type":"sast","ruleId":"G101","location":{"path":"main.go","positions":{"begin":{"line":2}}}},` +
+			`{"type":"sast","ruleId":"G101","location":{"path":"main_test.go","positions":{"begin":{"line":5}}},` +
+			`"suppressed":true,"justification":"test fixture"}]`),
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	findings, err := s.scanService.ListSuppressedFindings(context.Background(), scanID)
+	s.Require().NoError(err)
+	s.Require().Len(findings, 1)
+	s.Require().Equal("main_test.go", findings[0].Location.Path)
+	s.Require().Equal("test fixture", findings[0].Justification)
+}
+
+func (s *scanSuite) TestListSuppressedFindingsWithoutFindings() {
+	scanID := int64(1)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	findings, err := s.scanService.ListSuppressedFindings(context.Background(), scanID)
+	s.Require().NoError(err)
+	s.Require().Empty(findings)
+}
+
+func (s *scanSuite) TestListSuppressedFindingsWithNotFoundScan() {
+	scanID := int64(1)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	findings, err := s.scanService.ListSuppressedFindings(context.Background(), scanID)
+	s.Require().Error(err)
+	s.Require().Equal(codes.NotFound, status.Code(err))
+	s.Require().Nil(findings)
+}
+
+func (s *scanSuite) TestGetScan() {
+	scan := &models.Scan{
+		ID:       1,
+		Status:   models.ScanStatusSuccess,
+		Findings: []byte(`[{"ruleId":"G101","metadata":{"severity":"HIGH"}}]`),
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	detail, err := s.scanService.GetScan(context.Background(), scan.ID, &GetScanRequest{Fields: "id, status,summary"})
+	s.Require().NoError(err)
+	s.Require().Len(detail, 3)
+	s.Require().NotContains(detail, "findings")
+	summary := &models.ScanSummary{}
+	s.Require().NoError(json.Unmarshal(detail["summary"], summary))
+	s.Require().Equal(map[string]int{"HIGH": 1}, summary.BySeverity)
+}
+
+func (s *scanSuite) TestGetScanWithUnknownField() {
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), int64(1)).Return(&models.Scan{ID: 1}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.GetScan(context.Background(), 1, &GetScanRequest{Fields: "id,secret"})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *scanSuite) TestGetScanWithNotFoundRecord() {
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), int64(1)).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.GetScan(context.Background(), 1, &GetScanRequest{})
+	s.Require().Error(err)
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *scanSuite) TestGetScanDiffAgainstPreviousScan() {
+	scan := &models.Scan{
+		ID:           5,
+		RepositoryID: 1,
+		Ref:          "main",
+		Status:       models.ScanStatusSuccess,
+		Findings:     []byte(`[{"ruleId":"G101","fingerprint":"kept"},{"ruleId":"G101","fingerprint":"added"}]`),
+	}
+	previousScan := &models.Scan{
+		ID:           3,
+		RepositoryID: 1,
+		Ref:          "main",
+		Status:       models.ScanStatusSuccess,
+		Findings:     []byte(`[{"ruleId":"G101","fingerprint":"kept"},{"ruleId":"G101","fingerprint":"deleted"}]`),
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.scanRepo.EXPECT().List(gomock.Any(), 1, 1, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, size int, page int, filter *models.ScanFilter) ([]*models.Scan, error) {
+			s.Require().Equal(scan.RepositoryID, *filter.RepositoryID)
+			s.Require().Equal(models.ScanStatusSuccess, *filter.Status)
+			s.Require().Equal("main", *filter.Ref)
+			s.Require().False(*filter.History)
+			s.Require().Equal(scan.ID, *filter.BeforeID)
+			return []*models.Scan{previousScan}, nil
+		},
+	)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	diff, err := s.scanService.GetScanDiff(context.Background(), scan.ID, &GetScanDiffRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal(previousScan.ID, *diff.BaseScanID)
+	s.Require().Len(diff.New, 1)
+	s.Require().Equal("added", diff.New[0].Fingerprint)
+	s.Require().Len(diff.Fixed, 1)
+	s.Require().Equal("deleted", diff.Fixed[0].Fingerprint)
+	s.Require().Len(diff.Unchanged, 1)
+}
+
+func (s *scanSuite) TestGetScanDiffWithoutPreviousScanOfTheRef() {
+	scan := &models.Scan{
+		ID:           5,
+		RepositoryID: 1,
+		Ref:          "feature/login",
+		History:      true,
+		Status:       models.ScanStatusSuccess,
+		Findings:     []byte(`[{"ruleId":"G101","fingerprint":"added"}]`),
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	// the previous scans of the repository are of main, none matches
+	s.scanRepo.EXPECT().List(gomock.Any(), 1, 1, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, size int, page int, filter *models.ScanFilter) ([]*models.Scan, error) {
+			s.Require().Equal("feature/login", *filter.Ref)
+			s.Require().True(*filter.History)
+			return []*models.Scan{}, nil
+		},
+	)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	diff, err := s.scanService.GetScanDiff(context.Background(), scan.ID, &GetScanDiffRequest{})
+	s.Require().NoError(err)
+	s.Require().Nil(diff.BaseScanID)
+	s.Require().Len(diff.New, 1)
+	s.Require().Empty(diff.Fixed)
+}
+
+func (s *scanSuite) TestGetScanDiffWithBaseOfAnotherRepository() {
+	base := int64(3)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), int64(5)).
+		Return(&models.Scan{ID: 5, RepositoryID: 1, Status: models.ScanStatusSuccess}, nil)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), base).
+		Return(&models.Scan{ID: base, RepositoryID: 2, Status: models.ScanStatusSuccess}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	_, err := s.scanService.GetScanDiff(context.Background(), 5, &GetScanDiffRequest{Base: &base})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *scanSuite) TestGetScanDiffWithUnfinishedScan() {
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), int64(5)).
+		Return(&models.Scan{ID: 5, RepositoryID: 1, Status: models.ScanStatusInProgress}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.GetScanDiff(context.Background(), 5, &GetScanDiffRequest{})
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
 func (s *scanSuite) TestHandleResultMessageWithSuccess() {
 	scanID := int64(1)
 	timeNow := time.Now()
+	commitSHA := "2d4a8f3c9e1b7a6d5c4f3e2a1b0c9d8e7f6a5b4c"
 	messageResult := &models.ScanResultMessage{
 		ScanID:     scanID,
 		ScanStatus: models.ScanStatusSuccess,
+		CommitSHA:  commitSHA,
+		Stats:      &models.ScanStats{ScannedFiles: 12, IgnoredPaths: 1, BinaryFiles: 2},
 		FinishedAt: &timeNow,
 	}
 	changesets := map[string]interface{}{
 		"status":      models.ScanStatusSuccess,
+		"commit_sha":  commitSHA,
+		"stats":       []byte(`{"scanned_files":12,"ignored_paths":1,"binary_files":2,"oversized_files":0}`),
 		"finished_at": &timeNow,
 	}
-	s.scanRepo.EXPECT().UpdateWithMap(gomock.Any(), gomock.Any(), changesets).Return(nil)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusInProgress}, nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), changesets).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
+
+func (s *scanSuite) TestHandleResultMessageWithSuccessBeforeInProgress() {
+	scanID := int64(1)
+	timeNow := time.Now()
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusSuccess,
+		FinishedAt: &timeNow,
+	}
+	changesets := map[string]interface{}{
+		"status":      models.ScanStatusSuccess,
+		"finished_at": &timeNow,
+	}
+	// the In Progress message has not been handled yet
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusQueued}, nil)
+	s.scanRepo.EXPECT().
+		UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), []string{models.ScanStatusQueued, models.ScanStatusInProgress}, changesets).
+		Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
+
+func (s *scanSuite) TestHandleResultMessageWithSuccessAfterStaleFailure() {
+	scanID := int64(1)
+	timeNow := time.Now()
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusSuccess,
+		FinishedAt: &timeNow,
+	}
+	changesets := map[string]interface{}{
+		"status":        models.ScanStatusSuccess,
+		"error_code":    "",
+		"error_message": "",
+		"finished_at":   &timeNow,
+	}
+	// the stale checker gave up on the scan while it was still running
+	staleScan := &models.Scan{
+		ID:           scanID,
+		Status:       models.ScanStatusFailure,
+		ErrorCode:    models.ScanErrorStale,
+		ErrorMessage: "the scan made no progress for 5 minutes",
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(staleScan, nil)
+	s.scanRepo.EXPECT().UpdateStaleFailureWithMap(gomock.Any(), staleScan, changesets).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+	s.Require().Equal(models.ScanStatusSuccess, staleScan.Status)
+	s.Require().Empty(staleScan.ErrorCode)
+}
+
+func (s *scanSuite) TestHandleResultMessageWithInProgressAfterStaleFailure() {
+	scanID := int64(1)
+	timeNow := time.Now()
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusInProgress,
+		ScanningAt: &timeNow,
+	}
+	staleScan := &models.Scan{ID: scanID, Status: models.ScanStatusFailure, ErrorCode: models.ScanErrorStale}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(staleScan, nil)
 	s.repo.EXPECT().Scan().Return(s.scanRepo)
 
 	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
@@ -235,8 +649,9 @@ func (s *scanSuite) TestHandleResultMessageWithInProgress() {
 		"status":      models.ScanStatusInProgress,
 		"scanning_at": &timeNow,
 	}
-	s.scanRepo.EXPECT().UpdateWithMap(gomock.Any(), gomock.Any(), changesets).Return(nil)
-	s.repo.EXPECT().Scan().Return(s.scanRepo)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusQueued}, nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), changesets).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
 
 	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
 	s.Require().NoError(err)
@@ -246,16 +661,21 @@ func (s *scanSuite) TestHandleResultMessageWithFailure() {
 	scanID := int64(1)
 	timeNow := time.Now()
 	messageResult := &models.ScanResultMessage{
-		ScanID:     scanID,
-		ScanStatus: models.ScanStatusFailure,
-		FinishedAt: &timeNow,
+		ScanID:       scanID,
+		ScanStatus:   models.ScanStatusFailure,
+		ErrorCode:    models.ScanErrorRateLimited,
+		ErrorMessage: "rate limited by the host: GET /repos/acme/app/tarball: 429 Too Many Requests",
+		FinishedAt:   &timeNow,
 	}
 	changesets := map[string]interface{}{
-		"status":      models.ScanStatusFailure,
-		"finished_at": &timeNow,
+		"status":        models.ScanStatusFailure,
+		"error_code":    models.ScanErrorRateLimited,
+		"error_message": messageResult.ErrorMessage,
+		"finished_at":   &timeNow,
 	}
-	s.scanRepo.EXPECT().UpdateWithMap(gomock.Any(), gomock.Any(), changesets).Return(nil)
-	s.repo.EXPECT().Scan().Return(s.scanRepo)
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusInProgress}, nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), changesets).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
 
 	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
 	s.Require().NoError(err)
@@ -269,7 +689,276 @@ func (s *scanSuite) TestHandleResultMessageWithInvalidStatus() {
 		ScanStatus: "invalid_status",
 		FinishedAt: &timeNow,
 	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusInProgress}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
+
+func (s *scanSuite) TestHandleResultMessageRedeliveredAfterSuccess() {
+	scanID := int64(1)
+	timeNow := time.Now()
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusInProgress,
+		ScanningAt: &timeNow,
+	}
+	// the scan is not moved back to In Progress
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusSuccess}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
+
+func (s *scanSuite) TestHandleResultMessageRacingCancellation() {
+	scanID := int64(1)
+	timeNow := time.Now()
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusFailure,
+		FinishedAt: &timeNow,
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusInProgress}, nil)
+	// the scan was cancelled after it was read
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
+
+func (s *scanSuite) TestUpdateScanWithIllegalTransition() {
+	scan := &models.Scan{ID: 1, Status: models.ScanStatusSuccess}
+
+	_, err := s.scanService.UpdateScan(context.Background(), scan, &UpdateScanRequest{Status: models.ScanStatusInProgress})
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	s.Require().Equal(models.ScanStatusSuccess, scan.Status)
+}
+
+func (s *scanSuite) TestUpdateScanWithUnknownStatus() {
+	scan := &models.Scan{ID: 1, Status: models.ScanStatusInProgress}
+
+	_, err := s.scanService.UpdateScan(context.Background(), scan, &UpdateScanRequest{Status: "Done"})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *scanSuite) TestUpdateScanMovedConcurrently() {
+	scan := &models.Scan{ID: 1, Status: models.ScanStatusInProgress}
+	s.scanRepo.EXPECT().
+		UpdateWithMapFromStatuses(gomock.Any(), scan, []string{models.ScanStatusQueued, models.ScanStatusInProgress}, gomock.Any()).
+		Return(false, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.UpdateScan(context.Background(), scan, &UpdateScanRequest{Status: models.ScanStatusSuccess})
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *scanSuite) TestHandleResultMessageOfCancelledScan() {
+	scanID := int64(1)
+	timeNow := time.Now()
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusSuccess,
+		FinishedAt: &timeNow,
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusCancelled}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
+	s.Require().NoError(err)
+}
+
+func (s *scanSuite) TestHandleResultMessageWithInProgressOfCancelledScan() {
+	scanID := int64(1)
+	timeNow := time.Now()
+	messageResult := &models.ScanResultMessage{
+		ScanID:     scanID,
+		ScanStatus: models.ScanStatusInProgress,
+		ScanningAt: &timeNow,
+	}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scanID).Return(&models.Scan{ID: scanID, Status: models.ScanStatusCancelled}, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+	s.cancelWriter.EXPECT().WriteMessage(gomock.Any(), []byte(`{"scan_id":1}`)).Return(nil)
 
 	err := s.scanService.HandleResultMessage(context.Background(), messageResult)
 	s.Require().NoError(err)
 }
+
+func (s *scanSuite) TestCancelQueuedScan() {
+	scan := &models.Scan{ID: 1, Status: models.ScanStatusQueued}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.cancelWriter.EXPECT().WriteMessage(gomock.Any(), []byte(`{"scan_id":1}`)).Return(nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), scan, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.Scan, statuses []string, params map[string]interface{}) (bool, error) {
+			s.Require().Contains(statuses, models.ScanStatusQueued)
+			s.Require().Equal(models.ScanStatusCancelled, params["status"])
+			s.Require().NotNil(params["finished_at"])
+			return true, nil
+		},
+	)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	cancelledScan, err := s.scanService.CancelScan(context.Background(), scan.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(models.ScanStatusCancelled, cancelledScan.Status)
+	s.Require().NotNil(cancelledScan.FinishedAt)
+}
+
+func (s *scanSuite) TestCancelPendingScan() {
+	scan := &models.Scan{ID: 1, Status: models.ScanStatusPending}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), scan, gomock.Any(), gomock.Any()).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(2)
+
+	cancelledScan, err := s.scanService.CancelScan(context.Background(), scan.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(models.ScanStatusCancelled, cancelledScan.Status)
+}
+
+func (s *scanSuite) TestCancelScanWithFailedWritingMessage() {
+	scan := &models.Scan{ID: 1, Status: models.ScanStatusInProgress}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.cancelWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(errors.New("broker unavailable"))
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.CancelScan(context.Background(), scan.ID)
+	s.Require().Error(err)
+	s.Require().Equal(models.ScanStatusInProgress, scan.Status)
+}
+
+func (s *scanSuite) TestCancelFinishedScan() {
+	scan := &models.Scan{ID: 1, Status: models.ScanStatusSuccess}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.CancelScan(context.Background(), scan.ID)
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *scanSuite) TestRetryScan() {
+	repoID := int64(1)
+	repositoryURL := "https://github.com/vumanhcuongit/scan"
+	expectedRepository, _ := models.NewRepository(repositoryURL)
+	expectedRepository.ID = repoID
+	failedScan := &models.Scan{ID: 5, RepositoryID: repoID, Ref: "release/v1.2", History: true, Status: models.ScanStatusFailure}
+
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), failedScan.ID).Return(failedScan, nil)
+	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repoID).Return(expectedRepository, nil)
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), repoID).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
+	s.scanRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.Scan) (*models.Scan, error) {
+			s.Require().Equal(failedScan.ID, *record.ParentScanID)
+			s.Require().Equal(failedScan.Ref, record.Ref)
+			s.Require().True(record.History)
+			record.ID = 6
+			return record, nil
+		},
+	)
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, message []byte) error {
+			var requestMessage models.ScanRequestMessage
+			s.Require().NoError(json.Unmarshal(message, &requestMessage))
+			s.Require().Equal(int64(6), requestMessage.ScanID)
+			s.Require().Equal(failedScan.Ref, requestMessage.Ref)
+			s.Require().True(requestMessage.History)
+			return nil
+		},
+	)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(3)
+	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
+
+	scan, err := s.scanService.RetryScan(context.Background(), failedScan.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(6), scan.ID)
+	s.Require().Equal(failedScan.ID, *scan.ParentScanID)
+	s.Require().Equal(models.ScanStatusQueued, scan.Status)
+}
+
+func (s *scanSuite) TestRetrySuccessfulScan() {
+	scan := &models.Scan{ID: 5, Status: models.ScanStatusSuccess}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.RetryScan(context.Background(), scan.ID)
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *scanSuite) TestRetryCancelledScan() {
+	scan := &models.Scan{ID: 5, Status: models.ScanStatusCancelled}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	// a cancelled scan is rerun instead
+	_, err := s.scanService.RetryScan(context.Background(), scan.ID)
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func (s *scanSuite) TestRetryScanWithNotFoundScan() {
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), int64(5)).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.RetryScan(context.Background(), 5)
+	s.Require().Error(err)
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *scanSuite) TestRerunScan() {
+	repoID := int64(1)
+	expectedRepository, _ := models.NewRepository("https://github.com/vumanhcuongit/scan")
+	expectedRepository.ID = repoID
+	successfulScan := &models.Scan{ID: 5, RepositoryID: repoID, Ref: "release/v1.2", Status: models.ScanStatusSuccess}
+
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), successfulScan.ID).Return(successfulScan, nil)
+	s.repositoryRepo.EXPECT().GetByID(gomock.Any(), repoID).Return(expectedRepository, nil)
+	s.credentialRepo.EXPECT().GetByRepositoryID(gomock.Any(), repoID).Return(nil, gorm.ErrRecordNotFound)
+	s.repo.EXPECT().RepositoryCredential().Return(s.credentialRepo)
+	s.scanRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, record *models.Scan) (*models.Scan, error) {
+			s.Require().Equal(successfulScan.ID, *record.ParentScanID)
+			s.Require().Equal(successfulScan.Ref, record.Ref)
+			record.ID = 6
+			return record, nil
+		},
+	)
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
+	s.scanRepo.EXPECT().UpdateWithMapFromStatuses(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo).Times(3)
+	s.repo.EXPECT().Repository().Return(s.repositoryRepo)
+
+	scan, err := s.scanService.RerunScan(context.Background(), successfulScan.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(6), scan.ID)
+	s.Require().Equal(successfulScan.ID, *scan.ParentScanID)
+}
+
+func (s *scanSuite) TestRerunUnfinishedScan() {
+	scan := &models.Scan{ID: 5, Status: models.ScanStatusInProgress}
+	s.scanRepo.EXPECT().GetByID(gomock.Any(), scan.ID).Return(scan, nil)
+	s.repo.EXPECT().Scan().Return(s.scanRepo)
+
+	_, err := s.scanService.RerunScan(context.Background(), scan.ID)
+	s.Require().Error(err)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+}
+
+func TestIsValidRef(t *testing.T) {
+	validRefs := []string{"main", "release/v1.2", "v1.0.0", "2d4a8f3c9e1b7a6d5c4f3e2a1b0c9d8e7f6a5b4c", "feature/JIRA-123_fix"}
+	for _, ref := range validRefs {
+		require.True(t, isValidRef(ref), ref)
+	}
+
+	invalidRefs := []string{"-main", "/main", "main/", "main.", "main.lock", "a..b", "a//b", "a@{1}", "a b", "a~1", "a^", "a:b", "a?", "a*", "a[b", `a\b`, strings.Repeat("a", 256)}
+	for _, ref := range invalidRefs {
+		require.False(t, isValidRef(ref), ref)
+	}
+}
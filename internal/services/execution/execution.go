@@ -3,6 +3,9 @@ package execution
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/vumanhcuongit/scan/internal/config"
@@ -10,38 +13,74 @@ import (
 	"github.com/vumanhcuongit/scan/pkg/gitscan"
 	"github.com/vumanhcuongit/scan/pkg/kafka"
 	"github.com/vumanhcuongit/scan/pkg/models"
+	"github.com/vumanhcuongit/scan/pkg/secrets"
 	"go.uber.org/zap"
 )
 
+const (
+	// cancelPollInterval is how often a cancelled task is checked until asynq stops processing it
+	cancelPollInterval = 500 * time.Millisecond
+	maxCancelPolls     = 20
+)
+
+// taskInspector is the part of asynq.Inspector cancelling tasks.
+type taskInspector interface {
+	GetTaskInfo(queue, id string) (*asynq.TaskInfo, error)
+	DeleteTask(queue, id string) error
+	CancelProcessing(id string) error
+	Close() error
+}
+
 type Execution struct {
-	kafkaReader  *kafka.Reader
-	kafkaWriter  kafka.IWriter
-	jobManager   *job.Job      // job are processed concurrently by multiple workers
-	workerClient *asynq.Client // client puts tasks on a queue
-	workerServer *asynq.Server // server pulls tasks off queues and starts a worker goroutine for each task
-	workerMux    *asynq.ServeMux
+	kafkaReader     *kafka.Reader
+	cancelReader    *kafka.Reader // consumes the scans to cancel
+	kafkaWriter     kafka.IWriter
+	jobManager      *job.Job      // job are processed concurrently by multiple workers
+	workerClient    *asynq.Client // client puts tasks on a queue
+	workerServer    *asynq.Server // server pulls tasks off queues and starts a worker goroutine for each task
+	workerMux       *asynq.ServeMux
+	workerInspector taskInspector
 }
 
-func New(cfg *config.App, kafkaReader *kafka.Reader, kafkaWriter kafka.IWriter) *Execution {
-	gitScan := gitscan.NewGitScan(cfg.SourceCodesDir)
-	jobManager := job.NewJob(gitScan, kafkaWriter)
-	workerServer, workerMux, workerClient, err := SetupWorker(&cfg.RedisWorker, jobManager)
+func New(cfg *config.App, kafkaReader *kafka.Reader, cancelReader *kafka.Reader, kafkaWriter kafka.IWriter) *Execution {
+	registry, err := gitscan.LoadRegistry(&cfg.Scanner)
+	if err != nil {
+		panic(err)
+	}
+	gitScan := gitscan.NewGitScan(cfg.SourceCodesDir, registry, &cfg.Scanner)
+	var box *secrets.Box
+	if key := cfg.Secrets.EncryptionKey; key != "" {
+		box, err = secrets.NewBox(key)
+		if err != nil {
+			panic(err)
+		}
+	}
+	jobManager := job.NewJob(gitScan, kafkaWriter, box)
+	// a workspace older than a scan may last was orphaned by a crash, the others may
+	// belong to the scans of another worker sharing the directory
+	if err = gitscan.RemoveOrphanedWorkspaces(cfg.SourceCodesDir, job.ScanTimeout); err != nil {
+		panic(err)
+	}
+	workerServer, workerMux, workerClient, workerInspector, err := SetupWorker(&cfg.RedisWorker, jobManager)
 	if err != nil {
 		panic(err)
 	}
 
 	return &Execution{
-		kafkaReader:  kafkaReader,
-		kafkaWriter:  kafkaWriter,
-		jobManager:   jobManager,
-		workerServer: workerServer,
-		workerMux:    workerMux,
-		workerClient: workerClient,
+		kafkaReader:     kafkaReader,
+		cancelReader:    cancelReader,
+		kafkaWriter:     kafkaWriter,
+		jobManager:      jobManager,
+		workerServer:    workerServer,
+		workerMux:       workerMux,
+		workerClient:    workerClient,
+		workerInspector: workerInspector,
 	}
 }
 
 func (e *Execution) Stop() {
 	e.workerClient.Close()
+	e.workerInspector.Close()
 	e.workerServer.Shutdown()
 }
 
@@ -51,6 +90,12 @@ func (e *Execution) Run(ctx context.Context) error {
 		_ = zapLogger.Sync()
 	}()
 	log := zapLogger.Sugar()
+	go func() {
+		err := e.consumeCancellations(ctx)
+		if err != nil {
+			log.Errorf("failed to consume cancellations, err: %+v", err)
+		}
+	}()
 	return e.kafkaReader.Consume(ctx, func(ctx context.Context, message []byte) error {
 		log.Infof("starting to scan for request: %s", message)
 		var req models.ScanRequestMessage
@@ -60,12 +105,16 @@ func (e *Execution) Run(ctx context.Context) error {
 			return err
 		}
 
-		scanSourceCodejob, err := e.jobManager.NewScanSourceCodeJob(req.ScanID, req.Owner, req.Repository)
+		scanSourceCodejob, err := e.jobManager.NewScanSourceCodeJob(&req)
 		if err != nil {
 			log.Warnf("failed to create job: %v", err)
 			return err
 		}
 		jobInfo, err := e.workerClient.Enqueue(scanSourceCodejob)
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			log.Infof("scan %d is already enqueued", req.ScanID)
+			return nil
+		}
 		if err != nil {
 			log.Warnf("failed to enqueue job: %v", err)
 			return err
@@ -75,3 +124,66 @@ func (e *Execution) Run(ctx context.Context) error {
 		return nil
 	})
 }
+
+func (e *Execution) consumeCancellations(ctx context.Context) error {
+	log := zap.S()
+	return e.cancelReader.Consume(ctx, func(ctx context.Context, message []byte) error {
+		log.Infof("starting to cancel scan for request: %s", message)
+		var req models.ScanCancelMessage
+		err := json.Unmarshal(message, &req)
+		if err != nil {
+			log.Warnf("failed to unmarshal message, err: %+v", err)
+			return err
+		}
+
+		err = e.cancelTask(ctx, job.TaskID(req.ScanID))
+		if err != nil {
+			log.Warnf("failed to cancel task, err: %+v", err)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// cancelTask removes a task from its queue, a task being processed is cancelled first.
+// asynq retries a task whose processing was cancelled, so it is removed once it has
+// left the active state. A task not found was not enqueued yet or already finished.
+func (e *Execution) cancelTask(ctx context.Context, taskID string) error {
+	for poll := 0; poll < maxCancelPolls; poll++ {
+		taskInfo, err := e.workerInspector.GetTaskInfo(job.QueueScanSourceCode, taskID)
+		if errors.Is(err, asynq.ErrTaskNotFound) || errors.Is(err, asynq.ErrQueueNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch taskInfo.State {
+		case asynq.TaskStatePending, asynq.TaskStateScheduled, asynq.TaskStateRetry:
+			err = e.workerInspector.DeleteTask(taskInfo.Queue, taskID)
+			if errors.Is(err, asynq.ErrTaskNotFound) {
+				return nil
+			}
+			return err
+		case asynq.TaskStateActive:
+			if poll == 0 {
+				err = e.workerInspector.CancelProcessing(taskID)
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			// completed or archived, nothing runs anymore
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cancelPollInterval):
+		}
+	}
+
+	return fmt.Errorf("task %s is still being processed", taskID)
+}
@@ -1,10 +1,13 @@
 package execution
 
 import (
+	"context"
 	"testing"
 
+	"github.com/hibiken/asynq"
 	"github.com/stretchr/testify/require"
 	"github.com/vumanhcuongit/scan/internal/config"
+	"github.com/vumanhcuongit/scan/internal/services/execution/job"
 	"github.com/vumanhcuongit/scan/pkg/kafka"
 )
 
@@ -13,7 +16,7 @@ func TestNewExecution(t *testing.T) {
 	if err != nil {
 		require.NoError(t, err)
 	}
-	execution := New(cfg, &kafka.Reader{}, &kafka.Writer{})
+	execution := New(cfg, &kafka.Reader{}, &kafka.Reader{}, &kafka.Writer{})
 	require.NotNil(t, execution)
 }
 
@@ -22,8 +25,58 @@ func TestStop(t *testing.T) {
 	if err != nil {
 		require.NoError(t, err)
 	}
-	execution := New(cfg, &kafka.Reader{}, &kafka.Writer{})
+	execution := New(cfg, &kafka.Reader{}, &kafka.Reader{}, &kafka.Writer{})
 	require.NotNil(t, execution)
 
 	execution.Stop()
 }
+
+// fakeInspector plays a task that asynq moves to the retry state once it is cancelled.
+type fakeInspector struct {
+	state     asynq.TaskState
+	cancelled bool
+	deleted   bool
+}
+
+func (i *fakeInspector) GetTaskInfo(queue, id string) (*asynq.TaskInfo, error) {
+	if i.deleted {
+		return nil, asynq.ErrTaskNotFound
+	}
+	if i.cancelled {
+		i.state = asynq.TaskStateRetry
+	}
+	return &asynq.TaskInfo{ID: id, Queue: queue, State: i.state}, nil
+}
+
+func (i *fakeInspector) DeleteTask(queue, id string) error {
+	i.deleted = true
+	return nil
+}
+
+func (i *fakeInspector) CancelProcessing(id string) error {
+	i.cancelled = true
+	return nil
+}
+
+func (i *fakeInspector) Close() error {
+	return nil
+}
+
+func TestCancelTask(t *testing.T) {
+	inspector := &fakeInspector{state: asynq.TaskStatePending}
+	execution := &Execution{workerInspector: inspector}
+	require.NoError(t, execution.cancelTask(context.Background(), job.TaskID(1)))
+	require.False(t, inspector.cancelled)
+	require.True(t, inspector.deleted)
+
+	inspector = &fakeInspector{state: asynq.TaskStateActive}
+	execution = &Execution{workerInspector: inspector}
+	require.NoError(t, execution.cancelTask(context.Background(), job.TaskID(1)))
+	require.True(t, inspector.cancelled)
+	// not retried after its processing was cancelled
+	require.True(t, inspector.deleted)
+
+	inspector = &fakeInspector{deleted: true}
+	execution = &Execution{workerInspector: inspector}
+	require.NoError(t, execution.cancelTask(context.Background(), job.TaskID(1)))
+}
@@ -3,6 +3,7 @@ package job
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,41 +11,62 @@ import (
 	"github.com/vumanhcuongit/scan/pkg/gitscan"
 	"github.com/vumanhcuongit/scan/pkg/kafka"
 	"github.com/vumanhcuongit/scan/pkg/models"
+	"github.com/vumanhcuongit/scan/pkg/secrets"
 	"go.uber.org/zap"
 )
 
 const (
-	TypeScanSourceCode = "scan_source_code"
+	TypeScanSourceCode  = "scan_source_code"
+	QueueScanSourceCode = "default" // the queue asynq puts tasks on unless told otherwise
+
+	// ScanTimeout bounds a scan, its workspace is removed by then
+	ScanTimeout = 30 * time.Minute
 )
 
 type Job struct {
 	gitScan     gitscan.IGitScan
 	kafkaWriter kafka.IWriter
+	box         *secrets.Box // decrypts the credentials of private repositories, nil when not configured
 }
 
-func NewJob(gitScan gitscan.IGitScan, kafkaWriter kafka.IWriter) *Job {
+func NewJob(gitScan gitscan.IGitScan, kafkaWriter kafka.IWriter, box *secrets.Box) *Job {
 	return &Job{
 		gitScan:     gitScan,
 		kafkaWriter: kafkaWriter,
+		box:         box,
 	}
 }
 
 type ScanSourceCodePayload struct {
-	ScanID    int64
-	OwnerName string
-	RepoName  string
+	ScanID        int64
+	OwnerName     string
+	RepoName      string
+	RepositoryURL string
+	Ref           string
+	History       bool
+	Credential    *models.SealedCredential // stays encrypted while the task is queued
 }
 
-func (j *Job) NewScanSourceCodeJob(scanID int64, ownerName string, repoName string) (*asynq.Task, error) {
+func (j *Job) NewScanSourceCodeJob(request *models.ScanRequestMessage) (*asynq.Task, error) {
 	payload, err := json.Marshal(ScanSourceCodePayload{
-		ScanID:    scanID,
-		OwnerName: ownerName,
-		RepoName:  repoName,
+		ScanID:        request.ScanID,
+		OwnerName:     request.Owner,
+		RepoName:      request.Repository,
+		RepositoryURL: request.RepositoryURL,
+		Ref:           request.Ref,
+		History:       request.History,
+		Credential:    request.Credential,
 	})
 	if err != nil {
 		return nil, err
 	}
-	return asynq.NewTask(TypeScanSourceCode, payload), nil
+	return asynq.NewTask(TypeScanSourceCode, payload, asynq.TaskID(TaskID(request.ScanID)), asynq.Timeout(ScanTimeout)), nil
+}
+
+// TaskID identifies the task scanning a scan, so that it can be found to be cancelled
+// and a request delivered twice is only enqueued once.
+func TaskID(scanID int64) string {
+	return fmt.Sprintf("scan-%d", scanID)
 }
 
 func (j *Job) HandleScanSourceCodeJob(ctx context.Context, t *asynq.Task) error {
@@ -61,14 +83,42 @@ func (j *Job) HandleScanSourceCodeJob(ctx context.Context, t *asynq.Task) error
 		return err
 	}
 
-	findings, err := j.gitScan.Scan(ctx, payload.OwnerName, payload.RepoName)
+	credential, err := j.openCredential(payload.Credential)
 	if err != nil {
-		produceMessageErr := j.produceFailedResultMessage(ctx, &payload)
+		log.Warnf("failed to decrypt credential, err: %+v", err)
+		produceMessageErr := j.produceFailedResultMessage(ctx, &payload, models.ScanErrorInvalidCredential, err)
+		if produceMessageErr != nil {
+			log.Infof("failed to produce failed message, err: +%v", produceMessageErr)
+		}
+		return nil
+	}
+
+	result, err := j.gitScan.Scan(ctx, &gitscan.Target{
+		ScanID:     payload.ScanID,
+		URL:        payload.RepositoryURL,
+		Owner:      payload.OwnerName,
+		Repo:       payload.RepoName,
+		Ref:        payload.Ref,
+		History:    payload.History,
+		Credential: credential,
+	})
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// the scan was cancelled, the API service already knows it
+		log.Infof("stopped scanning scan %d, err: %+v", payload.ScanID, ctx.Err())
+		return ctx.Err()
+	}
+	if err != nil {
+		messageCtx := ctx
+		if ctx.Err() != nil {
+			// the task ran out of time, which is reported all the same
+			messageCtx = context.Background()
+		}
+		produceMessageErr := j.produceFailedResultMessage(messageCtx, &payload, gitscan.ErrorCode(err), err)
 		if produceMessageErr != nil {
 			log.Infof("failed to produce in progress message, err: +%v", produceMessageErr)
 		}
 	} else {
-		produceMessageErr := j.produceSuccessfulResultMessage(ctx, &payload, findings)
+		produceMessageErr := j.produceSuccessfulResultMessage(ctx, &payload, result)
 		if produceMessageErr != nil {
 			log.Infof("failed to produce succesful message, err: +%v", produceMessageErr)
 		}
@@ -77,6 +127,27 @@ func (j *Job) HandleScanSourceCodeJob(ctx context.Context, t *asynq.Task) error
 	return nil
 }
 
+// openCredential decrypts the credential of a private repository, public ones have none.
+func (j *Job) openCredential(sealed *models.SealedCredential) (*gitscan.Credential, error) {
+	if sealed == nil {
+		return nil, nil
+	}
+	if j.box == nil {
+		return nil, errors.New("the encryption key is not configured")
+	}
+
+	secret, err := j.box.Open(sealed.EncryptedSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitscan.Credential{
+		Kind:     sealed.Kind,
+		Username: sealed.Username,
+		Secret:   secret,
+	}, nil
+}
+
 func (j *Job) produceInProgressResultMessage(ctx context.Context, payload *ScanSourceCodePayload) error {
 	log := zap.S()
 	timeNow := time.Now()
@@ -102,14 +173,14 @@ func (j *Job) produceInProgressResultMessage(ctx context.Context, payload *ScanS
 func (j *Job) produceSuccessfulResultMessage(
 	ctx context.Context,
 	payload *ScanSourceCodePayload,
-	findings []models.Finding,
+	result *gitscan.Result,
 ) error {
 	log := zap.S()
 
 	var findingRepoJSON []byte
 	var marshalErr error
-	if len(findings) > 0 {
-		findingRepoJSON, marshalErr = json.Marshal(findings)
+	if len(result.Findings) > 0 {
+		findingRepoJSON, marshalErr = json.Marshal(result.Findings)
 		if marshalErr != nil {
 			log.Warnf("failed to marshal finding report, err: %+v", marshalErr)
 			return marshalErr
@@ -122,6 +193,8 @@ func (j *Job) produceSuccessfulResultMessage(
 		ScanStatus: models.ScanStatusSuccess,
 		FinishedAt: &timeNow,
 		Findings:   findingRepoJSON,
+		CommitSHA:  result.CommitSHA,
+		Stats:      result.Stats,
 	})
 	if err != nil {
 		log.Warnf("failed to marshal message, err: %+v", err)
@@ -137,14 +210,21 @@ func (j *Job) produceSuccessfulResultMessage(
 	return nil
 }
 
-func (j *Job) produceFailedResultMessage(ctx context.Context, payload *ScanSourceCodePayload) error {
+func (j *Job) produceFailedResultMessage(
+	ctx context.Context,
+	payload *ScanSourceCodePayload,
+	errorCode string,
+	scanErr error,
+) error {
 	log := zap.S()
 
 	timeNow := time.Now()
 	message, err := json.Marshal(models.ScanResultMessage{
-		ScanID:     payload.ScanID,
-		ScanStatus: models.ScanStatusFailure,
-		FinishedAt: &timeNow,
+		ScanID:       payload.ScanID,
+		ScanStatus:   models.ScanStatusFailure,
+		ErrorCode:    errorCode,
+		ErrorMessage: scanErr.Error(),
+		FinishedAt:   &timeNow,
 	})
 	if err != nil {
 		log.Warnf("failed to marshal message, err: %+v", err)
@@ -2,9 +2,12 @@ package job
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/hibiken/asynq"
@@ -13,6 +16,7 @@ import (
 	"github.com/vumanhcuongit/scan/pkg/gitscan"
 	"github.com/vumanhcuongit/scan/pkg/kafka"
 	"github.com/vumanhcuongit/scan/pkg/models"
+	"github.com/vumanhcuongit/scan/pkg/secrets"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +24,9 @@ const (
 	exampleScanID    = 1
 	exampleOwnerName = "vumanhcuongit"
 	exampleRepoName  = "scan"
+	exampleRef       = "main"
+	exampleURL       = "https://github.com/vumanhcuongit/scan"
+	exampleSHA       = "2d4a8f3c9e1b7a6d5c4f3e2a1b0c9d8e7f6a5b4c"
 )
 
 type jobSuite struct {
@@ -29,6 +36,7 @@ type jobSuite struct {
 	config      *config.App
 	kafkaWriter *kafka.MockIWriter
 	gitscan     *gitscan.MockIGitScan
+	box         *secrets.Box
 	job         *Job
 	exampleTask *asynq.Task
 }
@@ -56,11 +64,17 @@ func (s *jobSuite) SetupTest() {
 	s.mockCtrl = gomock.NewController(s.T())
 	s.kafkaWriter = kafka.NewMockIWriter(s.mockCtrl)
 	s.gitscan = gitscan.NewMockIGitScan(s.mockCtrl)
-	s.job = NewJob(s.gitscan, s.kafkaWriter)
+	box, err := secrets.NewBox(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", secrets.KeySize))))
+	s.Require().NoError(err)
+	s.box = box
+	s.job = NewJob(s.gitscan, s.kafkaWriter, s.box)
 	payload, _ := json.Marshal(ScanSourceCodePayload{
-		ScanID:    exampleScanID,
-		OwnerName: exampleOwnerName,
-		RepoName:  exampleRepoName,
+		ScanID:        exampleScanID,
+		OwnerName:     exampleOwnerName,
+		RepoName:      exampleRepoName,
+		RepositoryURL: exampleURL,
+		Ref:           exampleRef,
+		History:       true,
 	})
 	s.exampleTask = asynq.NewTask(TypeScanSourceCode, payload)
 }
@@ -70,9 +84,22 @@ func (s *jobSuite) TearDownTest() {
 }
 
 func (s *jobSuite) TestNewScanSourceCodeJob() {
-	job, err := s.job.NewScanSourceCodeJob(exampleScanID, exampleOwnerName, exampleRepoName)
+	job, err := s.job.NewScanSourceCodeJob(&models.ScanRequestMessage{
+		ScanID:        exampleScanID,
+		Owner:         exampleOwnerName,
+		Repository:    exampleRepoName,
+		RepositoryURL: exampleURL,
+		Ref:           exampleRef,
+		History:       true,
+	})
 	s.Require().NoError(err)
 	s.Require().NotNil(job)
+
+	var payload ScanSourceCodePayload
+	s.Require().NoError(json.Unmarshal(job.Payload(), &payload))
+	s.Require().Equal(exampleRef, payload.Ref)
+	s.Require().Equal(exampleURL, payload.RepositoryURL)
+	s.Require().True(payload.History)
 }
 
 func (s *jobSuite) TestHandleScanSourceCodeJobWithSuccessfulScan() {
@@ -86,10 +113,29 @@ func (s *jobSuite) TestHandleScanSourceCodeJobWithSuccessfulScan() {
 			RuleID: "1",
 		},
 	}
-	s.gitscan.EXPECT().Scan(gomock.Any(), exampleOwnerName, exampleRepoName).Return(exampleFindings, nil)
+	exampleTarget := &gitscan.Target{
+		ScanID:  exampleScanID,
+		URL:     exampleURL,
+		Owner:   exampleOwnerName,
+		Repo:    exampleRepoName,
+		Ref:     exampleRef,
+		History: true,
+	}
+	exampleStats := &models.ScanStats{ScannedFiles: 3, BinaryFiles: 1}
+	exampleResult := &gitscan.Result{CommitSHA: exampleSHA, Findings: exampleFindings, Stats: exampleStats}
+	s.gitscan.EXPECT().Scan(gomock.Any(), exampleTarget).Return(exampleResult, nil)
 
 	// produce successful result message
-	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, message []byte) error {
+			var result models.ScanResultMessage
+			s.Require().NoError(json.Unmarshal(message, &result))
+			s.Require().Equal(models.ScanStatusSuccess, result.ScanStatus)
+			s.Require().Equal(exampleSHA, result.CommitSHA)
+			s.Require().Equal(exampleStats, result.Stats)
+			return nil
+		},
+	)
 
 	err := s.job.HandleScanSourceCodeJob(context.Background(), s.exampleTask)
 	s.Require().NoError(err)
@@ -100,15 +146,132 @@ func (s *jobSuite) TestHandleScanSourceCodeJobWithFailedScan() {
 	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
 
 	// scan failed
-	s.gitscan.EXPECT().Scan(gomock.Any(), exampleOwnerName, exampleRepoName).Return(nil, errors.New("example error"))
+	exampleTarget := &gitscan.Target{
+		ScanID:  exampleScanID,
+		URL:     exampleURL,
+		Owner:   exampleOwnerName,
+		Repo:    exampleRepoName,
+		Ref:     exampleRef,
+		History: true,
+	}
+	s.gitscan.EXPECT().Scan(gomock.Any(), exampleTarget).Return(nil, gitscan.ErrArchiveTooLarge)
 
 	// produce failed result message
-	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, message []byte) error {
+			var result models.ScanResultMessage
+			s.Require().NoError(json.Unmarshal(message, &result))
+			s.Require().Equal(models.ScanStatusFailure, result.ScanStatus)
+			s.Require().Equal(models.ScanErrorArchiveTooLarge, result.ErrorCode)
+			s.Require().Equal(gitscan.ErrArchiveTooLarge.Error(), result.ErrorMessage)
+			return nil
+		},
+	)
 
 	err := s.job.HandleScanSourceCodeJob(context.Background(), s.exampleTask)
 	s.Require().NoError(err)
 }
 
+func (s *jobSuite) TestHandleScanSourceCodeJobWithCancelledScan() {
+	ctx, cancel := context.WithCancel(context.Background())
+	// produce in progress message only, a cancelled scan did not fail
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
+	s.gitscan.EXPECT().Scan(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, target *gitscan.Target) (*gitscan.Result, error) {
+			cancel()
+			return nil, ctx.Err()
+		},
+	)
+
+	err := s.job.HandleScanSourceCodeJob(ctx, s.exampleTask)
+	s.Require().ErrorIs(err, context.Canceled)
+}
+
+func (s *jobSuite) TestHandleScanSourceCodeJobWithTimedOutScan() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
+	s.gitscan.EXPECT().Scan(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, target *gitscan.Target) (*gitscan.Result, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	)
+	// the failure is reported even though the task ran out of time
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, message []byte) error {
+			s.Require().NoError(ctx.Err())
+			var result models.ScanResultMessage
+			s.Require().NoError(json.Unmarshal(message, &result))
+			s.Require().Equal(models.ScanErrorTimeout, result.ErrorCode)
+			return nil
+		},
+	)
+
+	err := s.job.HandleScanSourceCodeJob(ctx, s.exampleTask)
+	s.Require().NoError(err)
+}
+
+func (s *jobSuite) TestTaskID() {
+	s.Require().Equal("scan-42", TaskID(42))
+}
+
+func (s *jobSuite) TestHandleScanSourceCodeJobWithCredential() {
+	encryptedSecret, err := s.box.Seal("ghp_secret")
+	s.Require().NoError(err)
+	task, err := s.job.NewScanSourceCodeJob(&models.ScanRequestMessage{
+		ScanID:        exampleScanID,
+		Owner:         exampleOwnerName,
+		Repository:    exampleRepoName,
+		RepositoryURL: exampleURL,
+		Credential: &models.SealedCredential{
+			Kind:            models.CredentialKindToken,
+			EncryptedSecret: encryptedSecret,
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().NotContains(string(task.Payload()), "ghp_secret")
+
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	s.gitscan.EXPECT().Scan(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, target *gitscan.Target) (*gitscan.Result, error) {
+			s.Require().Equal(&gitscan.Credential{Kind: models.CredentialKindToken, Secret: "ghp_secret"}, target.Credential)
+			return &gitscan.Result{CommitSHA: exampleSHA}, nil
+		},
+	)
+
+	err = s.job.HandleScanSourceCodeJob(context.Background(), task)
+	s.Require().NoError(err)
+}
+
+func (s *jobSuite) TestHandleScanSourceCodeJobWithUndecryptableCredential() {
+	task, err := s.job.NewScanSourceCodeJob(&models.ScanRequestMessage{
+		ScanID:     exampleScanID,
+		Owner:      exampleOwnerName,
+		Repository: exampleRepoName,
+		Credential: &models.SealedCredential{
+			Kind:            models.CredentialKindToken,
+			EncryptedSecret: "bm90IHNlYWxlZCB3aXRoIHRoaXMga2V5",
+		},
+	})
+	s.Require().NoError(err)
+
+	// produce in progress then failed result messages, the repository is not fetched
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(nil)
+	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, message []byte) error {
+			var result models.ScanResultMessage
+			s.Require().NoError(json.Unmarshal(message, &result))
+			s.Require().Equal(models.ScanStatusFailure, result.ScanStatus)
+			s.Require().Equal(models.ScanErrorInvalidCredential, result.ErrorCode)
+			return nil
+		},
+	)
+
+	err = s.job.HandleScanSourceCodeJob(context.Background(), task)
+	s.Require().NoError(err)
+}
+
 func (s *jobSuite) TestdoWriteMessage() {
 	// failed to write message
 	s.kafkaWriter.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Return(errors.New("example error"))
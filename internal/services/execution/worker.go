@@ -9,7 +9,10 @@ import (
 	"go.uber.org/zap"
 )
 
-func SetupWorker(cfg *config.RedisWorkerConfig, jobManager *job.Job) (*asynq.Server, *asynq.ServeMux, *asynq.Client, error) {
+func SetupWorker(
+	cfg *config.RedisWorkerConfig,
+	jobManager *job.Job,
+) (*asynq.Server, *asynq.ServeMux, *asynq.Client, *asynq.Inspector, error) {
 	log := zap.S()
 	redisClientOpt, err := asynq.ParseRedisURI(cfg.RedisURL)
 	if err != nil {
@@ -17,6 +20,7 @@ func SetupWorker(cfg *config.RedisWorkerConfig, jobManager *job.Job) (*asynq.Ser
 	}
 
 	workerClient := asynq.NewClient(redisClientOpt)
+	workerInspector := asynq.NewInspector(redisClientOpt)
 	workerServer := asynq.NewServer(
 		redisClientOpt,
 		asynq.Config{
@@ -30,10 +34,10 @@ func SetupWorker(cfg *config.RedisWorkerConfig, jobManager *job.Job) (*asynq.Ser
 
 	if err := workerServer.Start(workerMux); err != nil {
 		log.Fatalf("failed to run server: %+v", err)
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 
-	return workerServer, workerMux, workerClient, nil
+	return workerServer, workerMux, workerClient, workerInspector, nil
 }
 
 func setupLog() asynq.MiddlewareFunc {
@@ -0,0 +1,3 @@
+ALTER TABLE scans
+    ADD COLUMN ref varchar(255) AFTER repository_url,
+    ADD COLUMN commit_sha varchar(40) AFTER ref;
@@ -0,0 +1,2 @@
+ALTER TABLE scans
+    ADD COLUMN history boolean NOT NULL DEFAULT false AFTER commit_sha;
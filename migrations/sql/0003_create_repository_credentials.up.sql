@@ -0,0 +1,11 @@
+CREATE TABLE repository_credentials (
+    id bigint PRIMARY KEY auto_increment,
+    repository_id bigint NOT NULL,
+    kind varchar(32) NOT NULL,
+    username varchar(255),
+    encrypted_secret text NOT NULL,
+    created_at  datetime,
+    updated_at  datetime,
+    CONSTRAINT repository_credentials_repository_id_fk FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX repository_credentials_repository_id_unique_idx ON repository_credentials(repository_id);
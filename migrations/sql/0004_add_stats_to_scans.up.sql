@@ -0,0 +1,2 @@
+ALTER TABLE scans
+    ADD COLUMN stats json AFTER findings;
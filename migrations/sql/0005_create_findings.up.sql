@@ -0,0 +1,19 @@
+CREATE TABLE findings (
+    id bigint PRIMARY KEY auto_increment,
+    repository_id bigint NOT NULL,
+    fingerprint char(64) NOT NULL,
+    rule_id varchar(255) NOT NULL,
+    path varchar(1024) NOT NULL,
+    severity varchar(32),
+    description text,
+    state varchar(32) NOT NULL,
+    comment text,
+    actor varchar(255),
+    first_scan_id bigint NOT NULL,
+    last_scan_id bigint NOT NULL,
+    triaged_at  datetime,
+    created_at  datetime,
+    updated_at  datetime,
+    CONSTRAINT findings_repository_id_fk FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX findings_repository_id_fingerprint_unique_idx ON findings(repository_id, fingerprint);
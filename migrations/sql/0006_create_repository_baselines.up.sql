@@ -0,0 +1,9 @@
+CREATE TABLE repository_baselines (
+    id bigint PRIMARY KEY auto_increment,
+    repository_id bigint NOT NULL,
+    fingerprints json NOT NULL,
+    created_at  datetime,
+    updated_at  datetime,
+    CONSTRAINT repository_baselines_repository_id_fk FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX repository_baselines_repository_id_unique_idx ON repository_baselines(repository_id);
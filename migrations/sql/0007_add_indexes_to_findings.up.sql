@@ -0,0 +1,4 @@
+CREATE INDEX findings_rule_id_idx ON findings(rule_id);
+CREATE INDEX findings_severity_idx ON findings(severity);
+CREATE INDEX findings_path_idx ON findings(path(255));
+CREATE INDEX findings_state_idx ON findings(state);
@@ -0,0 +1,3 @@
+ALTER TABLE scans
+    ADD COLUMN parent_scan_id bigint AFTER repository_id,
+    ADD CONSTRAINT scans_parent_scan_id_fk FOREIGN KEY (parent_scan_id) REFERENCES scans(id) ON DELETE SET NULL;
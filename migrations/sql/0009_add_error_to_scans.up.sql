@@ -0,0 +1,3 @@
+ALTER TABLE scans
+    ADD COLUMN error_code varchar(64) AFTER status,
+    ADD COLUMN error_message text AFTER error_code;
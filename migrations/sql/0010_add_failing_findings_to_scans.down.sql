@@ -0,0 +1,2 @@
+ALTER TABLE scans
+    DROP COLUMN failing_findings;
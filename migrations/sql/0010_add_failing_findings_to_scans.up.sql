@@ -0,0 +1,2 @@
+ALTER TABLE scans
+    ADD COLUMN failing_findings int NOT NULL DEFAULT 0 AFTER stats;
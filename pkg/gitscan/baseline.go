@@ -0,0 +1,36 @@
+package gitscan
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+)
+
+// loadBaseline reads the baseline committed at the root of the repository, nil when
+// there is none.
+func loadBaseline(repoDir string) *models.Baseline {
+	content, err := os.ReadFile(filepath.Join(repoDir, models.BaselineFile))
+	if err != nil {
+		return nil
+	}
+
+	return parseCommittedBaseline(content)
+}
+
+// parseCommittedBaseline ignores a malformed baseline rather than failing the scan,
+// its findings are then reported as failing, which is the safe side.
+func parseCommittedBaseline(content []byte) *models.Baseline {
+	if content == nil {
+		return nil
+	}
+
+	baseline, err := models.ParseBaseline(content)
+	if err != nil {
+		zap.S().Warnf("failed to parse %s, it is ignored, err: %+v", models.BaselineFile, err)
+		return nil
+	}
+
+	return baseline
+}
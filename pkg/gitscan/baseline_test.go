@@ -0,0 +1,87 @@
+package gitscan
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/internal/config"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestScanDirWithCommittedBaseline(t *testing.T) {
+	dir := t.TempDir()
+	secret := "private_key = \"secret\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.py"), []byte(secret), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings.py"), []byte(secret), 0o644))
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{}).(*GitScan)
+
+	findings, _, err := gitScan.scanDir(context.Background(), dir)
+	require.NoError(t, err)
+	findings = findingsOfRule(findings, "G101")
+	require.Len(t, findings, 2)
+	require.Equal(t, "config.py", findings[0].Location.Path)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, models.BaselineFile),
+		newBaselineFile(t, findings[0].Fingerprint), 0o644))
+
+	findings, stats, err := gitScan.scanDir(context.Background(), dir)
+	require.NoError(t, err)
+	findings = findingsOfRule(findings, "G101")
+	require.True(t, findings[0].Baselined)
+	require.False(t, findings[1].Baselined)
+	// the baseline itself is not scanned
+	require.Equal(t, 2, stats.ScannedFiles)
+}
+
+func TestScanDirWithMalformedBaseline(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.py"), []byte("private_key = \"secret\"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, models.BaselineFile), []byte(`{"fingerprints": "all"}`), 0o644))
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{}).(*GitScan)
+
+	findings, _, err := gitScan.scanDir(context.Background(), dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, findings)
+	for _, finding := range findings {
+		require.False(t, finding.Baselined)
+	}
+}
+
+func TestScanArchiveWithCommittedBaseline(t *testing.T) {
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{}).(*GitScan)
+	secret := "private_key = \"secret\"\n"
+	fingerprint := findingsOfRule(mustScanContent(t, gitScan, "config.py", secret), "G101")[0].Fingerprint
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(newTar(t,
+		regularEntry("app/config.py", secret),
+		regularEntry("app/"+models.BaselineFile, string(newBaselineFile(t, fingerprint))),
+		regularEntry("app/settings.py", secret),
+	))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	findings, _, err := gitScan.scanArchive(context.Background(), &buf)
+	require.NoError(t, err)
+	findings = findingsOfRule(findings, "G101")
+	require.Len(t, findings, 2)
+	require.True(t, findings[0].Baselined)
+	require.False(t, findings[1].Baselined)
+}
+
+func newBaselineFile(t *testing.T, fingerprints ...string) []byte {
+	content, err := json.Marshal(&models.Baseline{Fingerprints: fingerprints})
+	require.NoError(t, err)
+	return content
+}
+
+func mustScanContent(t *testing.T, gitScan *GitScan, path string, content string) []models.Finding {
+	findings, err := gitScan.scanContent(path, []byte(content))
+	require.NoError(t, err)
+	return findings
+}
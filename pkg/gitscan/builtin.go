@@ -0,0 +1,197 @@
+package gitscan
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+type builtinRegexRule struct {
+	id          string
+	description string
+	severity    string
+	regex       string
+	keywords    []string
+}
+
+// builtinRegexRules are the single line detectors shipped with the scanner.
+var builtinRegexRules = []builtinRegexRule{
+	{
+		id:          "G102",
+		description: "AWS access key ID",
+		severity:    SeverityHigh,
+		regex:       `\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`,
+		keywords:    []string{"akia", "asia", "abia", "acca"},
+	},
+	{
+		id:          "G103",
+		description: "AWS secret access key",
+		severity:    SeverityCritical,
+		regex:       `(?i)aws.{0,20}?(?:secret|private|sk).{0,20}?['"]?\s*[:=]{1,2}>?\s*['"]?([A-Za-z0-9/+]{40})\b`,
+		keywords:    []string{"aws"},
+	},
+	{
+		id:          "G104",
+		description: "GitHub token",
+		severity:    SeverityHigh,
+		regex:       `\b((?:gh[pousr]_[A-Za-z0-9]{36,255})|(?:github_pat_[A-Za-z0-9_]{22,255}))\b`,
+		keywords:    []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_"},
+	},
+	{
+		id:          "G105",
+		description: "Slack token",
+		severity:    SeverityHigh,
+		regex:       `\b(xox[baprs]-[0-9A-Za-z]{8,}-[0-9A-Za-z-]{8,})\b`,
+		keywords:    []string{"xox"},
+	},
+	{
+		id:          "G106",
+		description: "Slack incoming webhook URL",
+		severity:    SeverityMedium,
+		regex:       `(https://hooks\.slack\.com/services/T[A-Z0-9]{6,}/B[A-Z0-9]{6,}/[A-Za-z0-9]{20,})`,
+		keywords:    []string{"hooks.slack.com"},
+	},
+	{
+		id:          "G107",
+		description: "Stripe live API key",
+		severity:    SeverityCritical,
+		regex:       `\b((?:sk|rk)_live_[0-9A-Za-z]{24,99})\b`,
+		keywords:    []string{"sk_live_", "rk_live_"},
+	},
+	{
+		id:          "G109",
+		description: "JSON Web Token",
+		severity:    SeverityMedium,
+		regex:       `\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`,
+		keywords:    []string{"eyj"},
+	},
+}
+
+// DefaultRegistry returns a registry containing the built-in rules.
+func DefaultRegistry() *Registry {
+	registry, err := NewRegistry(
+		NewPrefixRule(
+			"G101", "Potential hardcoded credentials", SeverityHigh,
+			"private_key", "public_key",
+		),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, builtin := range builtinRegexRules {
+		rule, err := NewRegexRule(builtin.id, builtin.description, builtin.severity, builtin.regex, &RegexRuleOptions{
+			Keywords: builtin.keywords,
+		})
+		if err != nil {
+			panic(err)
+		}
+		if err = registry.Register(rule); err != nil {
+			panic(err)
+		}
+	}
+
+	for _, rule := range []FileRule{&PrivateKeyRule{}, &ServiceAccountRule{}} {
+		if err = registry.RegisterFileRule(rule); err != nil {
+			panic(err)
+		}
+	}
+
+	return registry
+}
+
+var privateKeyBlockRegex = regexp.MustCompile(
+	`-----BEGIN ((?:[A-Z0-9]+ )*)PRIVATE KEY(?: BLOCK)?-----([\s\S]*?)-----END ((?:[A-Z0-9]+ )*)PRIVATE KEY(?: BLOCK)?-----`,
+)
+
+var privateKeyMemberRegex = regexp.MustCompile(`"private_key"\s*:\s*"(?:[^"\\]|\\.)*"`)
+
+// minPrivateKeyBodyLength filters out documentation and code that only mention the PEM armor.
+const minPrivateKeyBodyLength = 64
+
+// PrivateKeyRule detects PEM encoded private keys, the finding spans the whole block.
+type PrivateKeyRule struct{}
+
+func (r *PrivateKeyRule) ID() string {
+	return "G108"
+}
+
+func (r *PrivateKeyRule) Description() string {
+	return "Private key"
+}
+
+func (r *PrivateKeyRule) Severity() string {
+	return SeverityCritical
+}
+
+func (r *PrivateKeyRule) MatchFile(path string, content []byte) []models.Finding {
+	if !bytes.Contains(content, []byte("PRIVATE KEY")) {
+		return nil
+	}
+
+	var findings []models.Finding
+	for _, match := range privateKeyBlockRegex.FindAllSubmatchIndex(content, -1) {
+		body := content[match[4]:match[5]]
+		if countBase64Chars(body) < minPrivateKeyBodyLength {
+			continue
+		}
+
+		findings = append(findings, newContentFinding(r, path, content, match[0], match[1]))
+	}
+
+	return findings
+}
+
+// ServiceAccountRule detects Google Cloud service account key files.
+type ServiceAccountRule struct{}
+
+func (r *ServiceAccountRule) ID() string {
+	return "G110"
+}
+
+func (r *ServiceAccountRule) Description() string {
+	return "Google Cloud service account key"
+}
+
+func (r *ServiceAccountRule) Severity() string {
+	return SeverityCritical
+}
+
+func (r *ServiceAccountRule) MatchFile(path string, content []byte) []models.Finding {
+	if !strings.HasSuffix(strings.ToLower(path), ".json") ||
+		!bytes.Contains(content, []byte("service_account")) {
+		return nil
+	}
+
+	var key struct {
+		Type       string `json:"type"`
+		PrivateKey string `json:"private_key"`
+	}
+	if err := json.Unmarshal(content, &key); err != nil {
+		return nil
+	}
+	if key.Type != "service_account" || key.PrivateKey == "" {
+		return nil
+	}
+
+	// the finding spans the "private_key" member, falling back to the whole file
+	start, end := 0, len(content)
+	if match := privateKeyMemberRegex.FindIndex(content); match != nil {
+		start, end = match[0], match[1]
+	}
+	return []models.Finding{newContentFinding(r, path, content, start, end)}
+}
+
+func countBase64Chars(data []byte) int {
+	count := 0
+	for _, c := range data {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '+' || c == '/' {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,213 @@
+package gitscan
+
+import (
+	"math"
+	"path"
+	"regexp"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+const (
+	EntropyRuleID = "G201"
+
+	defaultBase64EntropyThreshold = 4.5
+	defaultHexEntropyThreshold    = 3.0
+	defaultEntropyMinLength       = 20
+
+	maxBase64Entropy = 6.0 // log2(64)
+	maxHexEntropy    = 4.0 // log2(16)
+)
+
+var (
+	// quoted strings and the right hand side of assignments are the candidates for secrets
+	quotedStringRegex = regexp.MustCompile("\"((?:[^\"\\\\]|\\\\.)*)\"|'([^']*)'|`([^`]*)`")
+	assignmentRegex   = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_.-]*\s*(?::=|=>|[:=])\s*([^\s'"` + "`" + `,;]+)`)
+	base64TokenRegex  = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+	hexTokenRegex     = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+// lockFiles are full of checksums, which are high entropy by design.
+var lockFiles = map[string]struct{}{
+	"go.sum":            {},
+	"package-lock.json": {},
+	"yarn.lock":         {},
+	"pnpm-lock.yaml":    {},
+	"Cargo.lock":        {},
+	"Gemfile.lock":      {},
+	"composer.lock":     {},
+	"poetry.lock":       {},
+}
+
+type EntropyOptions struct {
+	Base64Threshold float64
+	HexThreshold    float64
+	MinLength       int
+}
+
+// EntropyRule reports base64 and hex tokens whose Shannon entropy is above a
+// threshold. It catches bespoke tokens that no regex rule knows about, so its
+// findings carry a confidence derived from how far above the threshold they are.
+type EntropyRule struct {
+	base64Threshold float64
+	hexThreshold    float64
+	minLength       int
+}
+
+func NewEntropyRule(opts *EntropyOptions) *EntropyRule {
+	rule := &EntropyRule{
+		base64Threshold: defaultBase64EntropyThreshold,
+		hexThreshold:    defaultHexEntropyThreshold,
+		minLength:       defaultEntropyMinLength,
+	}
+	if opts == nil {
+		return rule
+	}
+
+	if opts.Base64Threshold > 0 {
+		rule.base64Threshold = opts.Base64Threshold
+	}
+	if opts.HexThreshold > 0 {
+		rule.hexThreshold = opts.HexThreshold
+	}
+	if opts.MinLength > 0 {
+		rule.minLength = opts.MinLength
+	}
+
+	return rule
+}
+
+func (r *EntropyRule) ID() string {
+	return EntropyRuleID
+}
+
+func (r *EntropyRule) Description() string {
+	return "High entropy string"
+}
+
+func (r *EntropyRule) Severity() string {
+	return SeverityMedium
+}
+
+func (r *EntropyRule) Match(filePath string, line string) []models.Finding {
+	if len(line) < r.minLength {
+		return nil
+	}
+	if _, ok := lockFiles[path.Base(filePath)]; ok {
+		return nil
+	}
+
+	var findings []models.Finding
+	var reported [][2]int
+	for _, candidate := range candidateSpans(line) {
+		value := line[candidate[0]:candidate[1]]
+		for _, token := range base64TokenRegex.FindAllStringIndex(value, -1) {
+			span := [2]int{candidate[0] + token[0], candidate[0] + token[1]}
+			if overlapsAny(reported, span) {
+				continue
+			}
+
+			confidence, ok := r.score(line[span[0]:span[1]])
+			if !ok {
+				continue
+			}
+			reported = append(reported, span)
+			finding := newLineFinding(r, filePath, line, span[0], span[1])
+			finding.Metadata.Confidence = confidence
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+// score returns the confidence of a token being a secret, ok is false when
+// the token is too short or its entropy is below the threshold.
+func (r *EntropyRule) score(token string) (float64, bool) {
+	if len(token) < r.minLength {
+		return 0, false
+	}
+
+	threshold, maxEntropy := r.base64Threshold, maxBase64Entropy
+	if hexTokenRegex.MatchString(token) {
+		threshold, maxEntropy = r.hexThreshold, maxHexEntropy
+	} else if !containsLetterAndDigit(token) {
+		// long identifiers and words are not secrets even when their entropy is high
+		return 0, false
+	}
+
+	entropy := shannonEntropy(token)
+	if entropy < threshold {
+		return 0, false
+	}
+
+	confidence := 1.0
+	if maxEntropy > threshold {
+		confidence = math.Min(1, (entropy-threshold)/(maxEntropy-threshold))
+	}
+	// a token at the threshold is still more likely a secret than not
+	confidence = 0.5 + confidence/2
+
+	return math.Round(confidence*100) / 100, true
+}
+
+func candidateSpans(line string) [][2]int {
+	var spans [][2]int
+	for _, match := range quotedStringRegex.FindAllStringSubmatchIndex(line, -1) {
+		for group := 1; group <= 3; group++ {
+			if match[2*group] >= 0 {
+				spans = append(spans, [2]int{match[2*group], match[2*group+1]})
+			}
+		}
+	}
+	for _, match := range assignmentRegex.FindAllStringSubmatchIndex(line, -1) {
+		spans = append(spans, [2]int{match[2], match[3]})
+	}
+
+	return spans
+}
+
+// shannonEntropy returns the entropy of the string in bits per character.
+func shannonEntropy(data string) float64 {
+	if data == "" {
+		return 0
+	}
+
+	frequencies := map[rune]int{}
+	for _, c := range data {
+		frequencies[c]++
+	}
+
+	entropy := 0.0
+	length := float64(len(data))
+	for _, count := range frequencies {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+func overlapsAny(spans [][2]int, span [2]int) bool {
+	for _, other := range spans {
+		if span[0] < other[1] && other[0] < span[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsLetterAndDigit(token string) bool {
+	hasLetter, hasDigit := false, false
+	for _, c := range token {
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			hasLetter = true
+		}
+	}
+
+	return hasLetter && hasDigit
+}
@@ -0,0 +1,69 @@
+package gitscan
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	require.Equal(t, 0.0, shannonEntropy(""))
+	require.Equal(t, 0.0, shannonEntropy("aaaa"))
+	require.Equal(t, 1.0, shannonEntropy("abab"))
+	require.Equal(t, 4.0, shannonEntropy("0123456789abcdef"))
+}
+
+func TestEntropyRule(t *testing.T) {
+	rule := NewEntropyRule(nil)
+	testCases := []struct {
+		name     string
+		path     string
+		line     string
+		expected int
+	}{
+		{name: "quoted base64 token", path: "main.go", line: `token := "Zm9vYmFyOnN1cGVyc2VjcmV0LXRva2VuLTQyX3g5"`, expected: 1},
+		{name: "single quoted token", path: "app.py", line: `API_KEY = 'q8Rk2vXz7LmN4pWs9TgH3jYc6BdF1aEu'`, expected: 1},
+		{name: "unquoted assignment", path: ".env", line: `API_KEY=q8Rk2vXz7LmN4pWs9TgH3jYc6BdF1aEu`, expected: 1},
+		{name: "yaml assignment", path: "app.yaml", line: `  secret: q8Rk2vXz7LmN4pWs9TgH3jYc6BdF1aEu`, expected: 1},
+		{name: "hex token", path: "config.js", line: `const key = "8f3a9c1e7b2d4f60a5e8c3b1d9f7e2a4"`, expected: 1},
+		{name: "two tokens", path: "main.go", line: `a, b := "q8Rk2vXz7LmN4pWs9TgH3jYc6BdF1aEu", "Zm9vYmFyOnN1cGVyc2VjcmV0LXRva2VuLTQyX3g5"`, expected: 2},
+		{name: "short token", path: "main.go", line: `token := "q8Rk2vXz7LmN"`, expected: 0},
+		{name: "low entropy", path: "main.go", line: `token := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa1"`, expected: 0},
+		{name: "long identifier", path: "main.go", line: `name := "ThisIsAVeryLongIdentifierNameWithoutDigits"`, expected: 0},
+		{name: "sentence", path: "README.md", line: `title = "the quick brown fox jumps over the lazy dog 42 times"`, expected: 0},
+		{name: "unquoted prose", path: "README.md", line: `q8Rk2vXz7LmN4pWs9TgH3jYc6BdF1aEu appears in prose`, expected: 0},
+		{name: "lock file", path: "go.sum", line: `github.com/pkg/errors v0.9.1 h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4=`, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := rule.Match(tc.path, tc.line)
+			require.Len(t, findings, tc.expected)
+			for _, finding := range findings {
+				require.Equal(t, EntropyRuleID, finding.RuleID)
+				require.Equal(t, SeverityMedium, finding.Metadata.Severity)
+				require.GreaterOrEqual(t, finding.Metadata.Confidence, 0.5)
+				require.LessOrEqual(t, finding.Metadata.Confidence, 1.0)
+			}
+		})
+	}
+}
+
+func TestEntropyRuleOptions(t *testing.T) {
+	line := `token := "q8Rk2vXz7LmN4pWs9TgH3jYc6BdF1aEu"`
+
+	strict := NewEntropyRule(&EntropyOptions{Base64Threshold: 5.9})
+	require.Empty(t, strict.Match("main.go", line))
+
+	long := NewEntropyRule(&EntropyOptions{MinLength: 40})
+	require.Empty(t, long.Match("main.go", line))
+
+	lenient := NewEntropyRule(&EntropyOptions{Base64Threshold: 3})
+	findings := lenient.Match("main.go", line)
+	require.Len(t, findings, 1)
+	// the more the entropy exceeds the threshold, the higher the confidence
+	defaultFindings := NewEntropyRule(nil).Match("main.go", line)
+	require.Len(t, defaultFindings, 1)
+	require.Greater(t, findings[0].Metadata.Confidence, defaultFindings[0].Metadata.Confidence)
+	require.False(t, math.IsNaN(findings[0].Metadata.Confidence))
+}
@@ -0,0 +1,90 @@
+package gitscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+var (
+	ErrRepositoryNotFound = errors.New("repository not found")
+	ErrRefNotFound        = errors.New("ref not found")
+	ErrRateLimited        = errors.New("rate limited by the host")
+)
+
+// ErrorCode tells why a scan failed from the error Scan returned.
+func ErrorCode(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrRepositoryNotFound):
+		return models.ScanErrorRepoNotFound
+	case errors.Is(err, ErrRefNotFound):
+		return models.ScanErrorRefNotFound
+	case errors.Is(err, ErrRateLimited):
+		return models.ScanErrorRateLimited
+	case errors.Is(err, ErrArchiveTooLarge), errors.Is(err, ErrArchiveTooManyFiles):
+		return models.ScanErrorArchiveTooLarge
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.ScanErrorTimeout
+	}
+
+	return models.ScanErrorUnknown
+}
+
+// httpStatusError is the error of a request the host answered with another status than 200 OK.
+func httpStatusError(request *http.Request, resp *http.Response) error {
+	err := fmt.Errorf("GET %s: %s", request.URL.Path, resp.Status)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", ErrRepositoryNotFound, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", ErrRateLimited, err)
+	}
+
+	return err
+}
+
+// githubError tells a missing repository or ref and an exhausted rate limit apart from
+// the other failures of the GitHub API.
+func githubError(err error) error {
+	var rateLimitErr *github.RateLimitError
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	var responseErr *github.ErrorResponse
+	switch {
+	case errors.As(err, &rateLimitErr), errors.As(err, &abuseRateLimitErr):
+		return fmt.Errorf("%w: %v", ErrRateLimited, err)
+	case errors.As(err, &responseErr) && responseErr.Response != nil:
+		switch responseErr.Response.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %v", ErrRepositoryNotFound, err)
+		case http.StatusUnprocessableEntity:
+			// GitHub answers so when no commit matches the ref
+			return fmt.Errorf("%w: %v", ErrRefNotFound, err)
+		}
+	}
+
+	return err
+}
+
+// gitError tells a missing repository or ref apart from the other failures of git by
+// what git printed on its standard error.
+func gitError(err error, stderr string) error {
+	err = fmt.Errorf("%w: %s", err, stderr)
+	switch {
+	case strings.Contains(stderr, "couldn't find remote ref"):
+		return fmt.Errorf("%w: %v", ErrRefNotFound, err)
+	case strings.Contains(stderr, "Repository not found"),
+		strings.Contains(stderr, "does not appear to be a git repository"),
+		// prompts are disabled, git asks for credentials when the host hides a repository
+		strings.Contains(stderr, "could not read Username"):
+		return fmt.Errorf("%w: %v", ErrRepositoryNotFound, err)
+	}
+
+	return err
+}
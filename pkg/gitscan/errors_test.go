@@ -0,0 +1,63 @@
+package gitscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{err: fmt.Errorf("%w: GET /archive.tar.gz: 404 Not Found", ErrRepositoryNotFound), expected: models.ScanErrorRepoNotFound},
+		{err: gitError(errors.New("git fetch: exit status 128"), "fatal: couldn't find remote ref v9"), expected: models.ScanErrorRefNotFound},
+		{err: ErrArchiveTooLarge, expected: models.ScanErrorArchiveTooLarge},
+		{err: ErrArchiveTooManyFiles, expected: models.ScanErrorArchiveTooLarge},
+		{err: context.DeadlineExceeded, expected: models.ScanErrorTimeout},
+		{err: &url.Error{Op: "Get", URL: "https://github.com", Err: timeoutError{}}, expected: models.ScanErrorTimeout},
+		{err: ErrUnsafeArchivePath, expected: models.ScanErrorUnknown},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, ErrorCode(tc.err), tc.err.Error())
+	}
+}
+
+func TestGitError(t *testing.T) {
+	exitErr := errors.New("git fetch: exit status 128")
+	err := gitError(exitErr, "remote: Repository not found.\nfatal: repository 'https://github.com/acme/app/' not found")
+	require.ErrorIs(t, err, ErrRepositoryNotFound)
+	require.Contains(t, err.Error(), "git fetch: exit status 128")
+
+	err = gitError(exitErr, "fatal: unable to access 'https://github.com/acme/app/': Could not resolve host: github.com")
+	require.False(t, errors.Is(err, ErrRepositoryNotFound))
+	require.Equal(t, models.ScanErrorUnknown, ErrorCode(err))
+}
+
+func TestGitHubError(t *testing.T) {
+	response := func(statusCode int) *http.Response {
+		return &http.Response{StatusCode: statusCode, Request: &http.Request{Method: http.MethodGet, URL: &url.URL{}}}
+	}
+
+	require.ErrorIs(t, githubError(&github.ErrorResponse{Response: response(http.StatusNotFound)}), ErrRepositoryNotFound)
+	require.ErrorIs(t, githubError(&github.ErrorResponse{Response: response(http.StatusUnprocessableEntity)}), ErrRefNotFound)
+	require.ErrorIs(t, githubError(&github.RateLimitError{Response: response(http.StatusForbidden)}), ErrRateLimited)
+	require.ErrorIs(t, githubError(&github.AbuseRateLimitError{Response: response(http.StatusForbidden)}), ErrRateLimited)
+
+	err := &github.ErrorResponse{Response: response(http.StatusInternalServerError)}
+	require.Same(t, err, githubError(err))
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,225 @@
+package gitscan
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/internal/config"
+)
+
+const (
+	defaultArchiveMaxBytes = 1 << 30 // 1 GiB
+	defaultArchiveMaxFiles = 100000
+)
+
+var (
+	ErrUnsafeArchivePath   = errors.New("archive entry escapes the destination")
+	ErrArchiveTooLarge     = errors.New("archive exceeds the maximum extracted size")
+	ErrArchiveTooManyFiles = errors.New("archive exceeds the maximum number of entries")
+)
+
+// ExtractLimits bound what an archive may write on the disk.
+type ExtractLimits struct {
+	MaxBytes int64 // total size of the extracted files
+	MaxFiles int   // number of entries, directories included
+}
+
+// newExtractLimits falls back to the defaults for the limits left unset.
+func newExtractLimits(cfg *config.ArchiveConfig) ExtractLimits {
+	limits := ExtractLimits{MaxBytes: cfg.MaxBytes, MaxFiles: cfg.MaxFiles}
+	if limits.MaxBytes <= 0 {
+		limits.MaxBytes = defaultArchiveMaxBytes
+	}
+	if limits.MaxFiles <= 0 {
+		limits.MaxFiles = defaultArchiveMaxFiles
+	}
+
+	return limits
+}
+
+// extractTarGz extracts a gzipped tarball under destination, see extractTar.
+func extractTarGz(ctx context.Context, reader io.Reader, destination string, limits ExtractLimits) error {
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	return extractTar(ctx, gz, destination, limits)
+}
+
+// extractTar extracts the directories and the regular files of a tarball under
+// destination. Entries that would land outside of it are rejected and symlinks are
+// never created, so no later entry can be written through one. Hardlinks become
+// copies of files extracted before them. Devices and fifos are skipped.
+func extractTar(ctx context.Context, reader io.Reader, destination string, limits ExtractLimits) error {
+	root, err := filepath.Abs(destination)
+	if err != nil {
+		return err
+	}
+
+	e := &extraction{root: root, remainingBytes: limits.MaxBytes, remainingFiles: limits.MaxFiles}
+	tr := tar.NewReader(reader)
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			name, err := localPath(hdr.Name)
+			if err != nil {
+				return err
+			}
+			err = e.makeDirs(name)
+		case tar.TypeReg, tar.TypeRegA:
+			err = e.writeEntry(hdr.Name, tr)
+		case tar.TypeLink:
+			err = e.copyEntry(hdr.Name, hdr.Linkname)
+		default:
+			// GitHub stores the commit in a pax global header, symlinks, devices
+			// and fifos have no content to scan either
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extraction tracks what an archive wrote so far.
+type extraction struct {
+	root           string
+	remainingBytes int64
+	remainingFiles int
+}
+
+// makeDirs creates the missing directories of name. Each one counts as an entry,
+// a single deep path would otherwise create any number of them.
+func (e *extraction) makeDirs(name string) error {
+	if name == "." {
+		return nil
+	}
+
+	dir := e.root
+	for _, segment := range strings.Split(name, string(filepath.Separator)) {
+		dir = filepath.Join(dir, segment)
+		info, err := os.Lstat(dir)
+		if err == nil && info.IsDir() {
+			continue
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if err = e.addFile(); err != nil {
+			return err
+		}
+		if err = os.Mkdir(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeEntry writes a regular file, it is closed before returning.
+func (e *extraction) writeEntry(entryName string, content io.Reader) error {
+	name, err := localPath(entryName)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return fmt.Errorf("%w: %q", ErrUnsafeArchivePath, entryName)
+	}
+	if err = e.makeDirs(filepath.Dir(name)); err != nil {
+		return err
+	}
+	if err = e.addFile(); err != nil {
+		return err
+	}
+
+	target := filepath.Join(e.root, name)
+	// an earlier entry of the same name is replaced
+	if err = os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	written, err := io.Copy(file, io.LimitReader(content, e.remainingBytes+1))
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	if written > e.remainingBytes {
+		return ErrArchiveTooLarge
+	}
+	e.remainingBytes -= written
+
+	return closeErr
+}
+
+// copyEntry materializes a hardlink, its target must be a regular file extracted before.
+func (e *extraction) copyEntry(entryName string, linkName string) error {
+	sourceName, err := localPath(linkName)
+	if err != nil {
+		return err
+	}
+	source := filepath.Join(e.root, sourceName)
+	info, err := os.Lstat(source)
+	if err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: hardlink to %q", ErrUnsafeArchivePath, linkName)
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return e.writeEntry(entryName, file)
+}
+
+func (e *extraction) addFile() error {
+	if e.remainingFiles <= 0 {
+		return ErrArchiveTooManyFiles
+	}
+	e.remainingFiles--
+
+	return nil
+}
+
+// localPath cleans the name of an entry and rejects the names that are absolute
+// or climb above the destination.
+func localPath(name string) (string, error) {
+	if name == "" || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeArchivePath, name)
+	}
+
+	cleaned := path.Clean(name)
+	if path.IsAbs(cleaned) || filepath.VolumeName(cleaned) != "" ||
+		cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeArchivePath, name)
+	}
+
+	return filepath.FromSlash(cleaned), nil
+}
@@ -0,0 +1,221 @@
+package gitscan
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testExtractLimits = ExtractLimits{MaxBytes: 1 << 20, MaxFiles: 100}
+
+// tarEntry is an entry of a crafted tarball, the size of regular files is set from the content.
+type tarEntry struct {
+	header  tar.Header
+	content string
+}
+
+func newTar(t testing.TB, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		header := entry.header
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(entry.content))
+		}
+		if header.Mode == 0 && header.Typeflag != tar.TypeXGlobalHeader {
+			header.Mode = 0o644
+		}
+		require.NoError(t, tw.WriteHeader(&header))
+		if entry.content != "" {
+			_, err := tw.Write([]byte(entry.content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+
+	return buf.Bytes()
+}
+
+func regularEntry(name string, content string) tarEntry {
+	return tarEntry{header: tar.Header{Name: name, Typeflag: tar.TypeReg}, content: content}
+}
+
+func linkEntry(typeflag byte, name string, linkName string) tarEntry {
+	return tarEntry{header: tar.Header{Name: name, Linkname: linkName, Typeflag: typeflag}}
+}
+
+func TestExtractTar(t *testing.T) {
+	archive := newTar(t,
+		tarEntry{header: tar.Header{Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": exampleCommitSHA}}},
+		tarEntry{header: tar.Header{Name: "acme-app-2d4a8f3/", Typeflag: tar.TypeDir, Mode: 0o777}},
+		regularEntry("acme-app-2d4a8f3/config.py", "private_key = \"secret\"\n"),
+		tarEntry{header: tar.Header{Name: "acme-app-2d4a8f3/bin/run.sh", Typeflag: tar.TypeReg, Mode: 0o4777}, content: "#!/bin/sh\n"},
+		linkEntry(tar.TypeSymlink, "acme-app-2d4a8f3/passwd", "/etc/passwd"),
+		linkEntry(tar.TypeLink, "acme-app-2d4a8f3/settings.py", "acme-app-2d4a8f3/config.py"),
+		tarEntry{header: tar.Header{Name: "acme-app-2d4a8f3/fifo", Typeflag: tar.TypeFifo}},
+	)
+	dir := t.TempDir()
+
+	require.NoError(t, extractTar(context.Background(), bytes.NewReader(archive), dir, testExtractLimits))
+
+	content, err := os.ReadFile(filepath.Join(dir, "acme-app-2d4a8f3", "config.py"))
+	require.NoError(t, err)
+	require.Equal(t, "private_key = \"secret\"\n", string(content))
+	info, err := os.Stat(filepath.Join(dir, "acme-app-2d4a8f3", "bin", "run.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o644), info.Mode())
+
+	// hardlinks are copies, symlinks and fifos are skipped
+	content, err = os.ReadFile(filepath.Join(dir, "acme-app-2d4a8f3", "settings.py"))
+	require.NoError(t, err)
+	require.Equal(t, "private_key = \"secret\"\n", string(content))
+	for _, name := range []string{"passwd", "fifo"} {
+		_, err = os.Lstat(filepath.Join(dir, "acme-app-2d4a8f3", name))
+		require.True(t, os.IsNotExist(err), name)
+	}
+}
+
+func TestExtractTarRejectsUnsafeEntries(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{name: "parent directory", entries: []tarEntry{regularEntry("../evil.sh", "evil")}},
+		{name: "nested parent directory", entries: []tarEntry{regularEntry("app/../../evil.sh", "evil")}},
+		{name: "absolute path", entries: []tarEntry{regularEntry("/tmp/evil.sh", "evil")}},
+		{name: "file at the root", entries: []tarEntry{regularEntry(".", "evil")}},
+		{name: "hardlink outside", entries: []tarEntry{linkEntry(tar.TypeLink, "app/passwd", "../../etc/passwd")}},
+		{name: "absolute hardlink", entries: []tarEntry{linkEntry(tar.TypeLink, "app/passwd", "/etc/passwd")}},
+		{name: "hardlink to a missing file", entries: []tarEntry{linkEntry(tar.TypeLink, "app/a", "app/b")}},
+		{
+			name: "hardlink to a directory",
+			entries: []tarEntry{
+				{header: tar.Header{Name: "app/", Typeflag: tar.TypeDir}},
+				linkEntry(tar.TypeLink, "copy", "app"),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "dest")
+			require.NoError(t, os.Mkdir(dir, 0o755))
+
+			err := extractTar(context.Background(), bytes.NewReader(newTar(t, tc.entries...)), dir, testExtractLimits)
+			require.ErrorIs(t, err, ErrUnsafeArchivePath)
+			requireInside(t, parent, dir)
+		})
+	}
+}
+
+func TestExtractTarDoesNotFollowSymlinks(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "dest")
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	archive := newTar(t,
+		linkEntry(tar.TypeSymlink, "escape", ".."),
+		regularEntry("escape/evil.sh", "evil"),
+	)
+
+	require.NoError(t, extractTar(context.Background(), bytes.NewReader(archive), dir, testExtractLimits))
+	requireInside(t, parent, dir)
+	require.FileExists(t, filepath.Join(dir, "escape", "evil.sh"))
+}
+
+func TestExtractTarLimits(t *testing.T) {
+	limits := ExtractLimits{MaxBytes: 10, MaxFiles: 3}
+	testCases := []struct {
+		name     string
+		entries  []tarEntry
+		expected error
+	}{
+		{name: "within limits", entries: []tarEntry{regularEntry("a", "12345"), regularEntry("b", "12345")}},
+		{name: "too large", entries: []tarEntry{regularEntry("a", "12345"), regularEntry("b", "123456")}, expected: ErrArchiveTooLarge},
+		{
+			name:     "hardlinks count",
+			entries:  []tarEntry{regularEntry("a", "123456"), linkEntry(tar.TypeLink, "b", "a")},
+			expected: ErrArchiveTooLarge,
+		},
+		{
+			name:     "too many files",
+			entries:  []tarEntry{regularEntry("a", ""), regularEntry("b", ""), regularEntry("c", ""), regularEntry("d", "")},
+			expected: ErrArchiveTooManyFiles,
+		},
+		{
+			name:     "directories count",
+			entries:  []tarEntry{regularEntry("a/b/c/d", "")},
+			expected: ErrArchiveTooManyFiles,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := extractTar(context.Background(), bytes.NewReader(newTar(t, tc.entries...)), t.TempDir(), limits)
+			if tc.expected == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestExtractTarWithCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := extractTar(ctx, bytes.NewReader(newTar(t, regularEntry("a", "1"))), t.TempDir(), testExtractLimits)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func FuzzExtractTar(f *testing.F) {
+	f.Add(newTar(f, regularEntry("app/config.py", "private_key = \"secret\"\n")))
+	f.Add(newTar(f, regularEntry("../evil.sh", "evil")))
+	f.Add(newTar(f, linkEntry(tar.TypeSymlink, "escape", ".."), regularEntry("escape/evil.sh", "evil")))
+	f.Add(newTar(f, regularEntry("a", "content"), linkEntry(tar.TypeLink, "b", "a"), linkEntry(tar.TypeLink, "c", "../a")))
+	f.Add(newTar(f, tarEntry{header: tar.Header{Name: "app/", Typeflag: tar.TypeDir}}, regularEntry("app", "file over dir")))
+
+	f.Fuzz(func(t *testing.T, archive []byte) {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "dest")
+		require.NoError(t, os.Mkdir(dir, 0o755))
+
+		_ = extractTar(context.Background(), bytes.NewReader(archive), dir, testExtractLimits)
+		requireInside(t, parent, dir)
+	})
+}
+
+// requireInside checks that the extraction wrote nothing but directories and regular
+// files, all under dir, and stayed within the limits.
+func requireInside(t *testing.T, parent string, dir string) {
+	t.Helper()
+	var size int64
+	files := 0
+	err := filepath.Walk(parent, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == parent || path == dir {
+			return nil
+		}
+		if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return errors.New("extracted outside of the destination: " + path)
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return errors.New("extracted a special file: " + path)
+		}
+		size += info.Size()
+		files++
+		return nil
+	})
+	require.NoError(t, err)
+	require.LessOrEqual(t, size, testExtractLimits.MaxBytes)
+	require.LessOrEqual(t, files, testExtractLimits.MaxFiles)
+}
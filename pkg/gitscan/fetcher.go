@@ -0,0 +1,82 @@
+package gitscan
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	FetcherArchive = "archive"
+	FetcherGit     = "git"
+)
+
+// Fetcher places the source code of a target on the local disk.
+type Fetcher interface {
+	// Fetch downloads the target under dir, an empty directory the caller removes when done with it.
+	Fetch(ctx context.Context, target *Target, dir string) (*Checkout, error)
+}
+
+// Checkout is the source code of a target fetched on the local disk.
+type Checkout struct {
+	Dir       string // root of the source tree
+	CommitSHA string // commit the source tree belongs to
+}
+
+// ArchiveProvider is a provider serving the source code of a target as a gzipped
+// tarball, which can be scanned as it downloads without touching the disk.
+type ArchiveProvider interface {
+	SourceProvider
+	// OpenArchive starts the download of the tarball, the caller closes it.
+	OpenArchive(ctx context.Context, target *Target) (*Archive, error)
+}
+
+// Archive is the gzipped tarball of a target. The source tree is in a single top
+// level directory whose name depends on the host.
+type Archive struct {
+	io.ReadCloser
+	CommitSHA string // commit the tarball was built from
+}
+
+// downloadArchive starts the download of an archive. The body is read as long as the
+// archive is extracted or scanned, the context of the request bounds it instead of
+// the timeout of the client.
+func downloadArchive(httpClient *http.Client, request *http.Request) (io.ReadCloser, error) {
+	archiveClient := *httpClient
+	archiveClient.Timeout = 0
+	resp, err := archiveClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, httpStatusError(request, resp)
+	}
+
+	return resp.Body, nil
+}
+
+// extractArchive downloads the archive of a target under dir, the checkout is its top level directory.
+func extractArchive(ctx context.Context, provider ArchiveProvider, target *Target, dir string, limits ExtractLimits) (*Checkout, error) {
+	log := zap.S()
+	archive, err := provider.OpenArchive(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	if err = extractTarGz(ctx, archive, dir, limits); err != nil {
+		log.Warnf("failed to extract archive, err: %+v", err)
+		return nil, err
+	}
+
+	repoDir, err := archiveRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkout{Dir: repoDir, CommitSHA: archive.CommitSHA}, nil
+}
@@ -0,0 +1,198 @@
+package gitscan
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxFileSize = 1 << 20 // 1 MiB
+
+	// scanIgnoreFile lists the paths to skip in gitignore syntax, it is read from the root of the source tree
+	scanIgnoreFile = ".scanignore"
+
+	// sniffLength is how much of a file git looks at to tell a binary file, one containing a NUL byte
+	sniffLength = 8000
+)
+
+// vendoredPatterns are the directories of third party code, skipped unless a
+// negated pattern of .scanignore brings them back.
+var vendoredPatterns = []string{
+	"node_modules/",
+	"bower_components/",
+	"vendor/",
+	"third_party/",
+	".venv/",
+}
+
+// ignoreRule is a pattern of a gitignore file.
+type ignoreRule struct {
+	glob    *glob
+	negated bool // the path is scanned again
+	dirOnly bool // matches directories only
+}
+
+// ignoreList decides which paths a scan skips, the last rule matching a path wins.
+// As with git, nothing under an ignored directory can be brought back.
+type ignoreList struct {
+	rules []*ignoreRule
+}
+
+func newIgnoreList() *ignoreList {
+	l := &ignoreList{}
+	l.add([]byte(strings.Join(vendoredPatterns, "\n")))
+
+	return l
+}
+
+// loadIgnoreList adds the patterns of the .scanignore file at the root of the source tree to the defaults.
+func loadIgnoreList(repoDir string, maxFileSize int64) *ignoreList {
+	log := zap.S()
+	ignores := newIgnoreList()
+	path := filepath.Join(repoDir, scanIgnoreFile)
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+		return ignores
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Warnf("failed to read %s, err: %+v", scanIgnoreFile, err)
+		return ignores
+	}
+	ignores.add(content)
+
+	return ignores
+}
+
+// add parses the content of a gitignore file. Invalid patterns are skipped, git does not fail on them either.
+func (l *ignoreList) add(content []byte) {
+	log := zap.S()
+	for i, line := range strings.Split(string(content), "\n") {
+		rule, err := parseIgnoreRule(line)
+		if err != nil {
+			log.Warnf("skipping line %d of %s, err: %+v", i+1, scanIgnoreFile, err)
+			continue
+		}
+		if rule != nil {
+			l.rules = append(l.rules, rule)
+		}
+	}
+}
+
+// parseIgnoreRule reads a line of a gitignore file, blank lines and comments have no rule.
+func parseIgnoreRule(line string) (*ignoreRule, error) {
+	line = strings.TrimSuffix(line, "\r")
+	// trailing spaces are dropped unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	rule := &ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negated = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	// a slash anywhere but at the end anchors the pattern to the root
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	g, err := compileGlob(line)
+	if err != nil {
+		return nil, err
+	}
+	g.baseOnly = !anchored
+	rule.glob = g
+
+	return rule, nil
+}
+
+// ignored tells whether a path relative to the root is skipped, its parents are not looked at.
+func (l *ignoreList) ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range l.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.glob.Match(path) {
+			ignored = !rule.negated
+		}
+	}
+
+	return ignored
+}
+
+// match returns the topmost ignored directory of a file, or the file itself, when the file is skipped.
+func (l *ignoreList) match(path string) (string, bool) {
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && l.ignored(path[:i], true) {
+			return path[:i], true
+		}
+	}
+	if l.ignored(path, false) {
+		return path, true
+	}
+
+	return "", false
+}
+
+// isBinary sniffs the content the way git does.
+func isBinary(content []byte) bool {
+	if len(content) > sniffLength {
+		content = content[:sniffLength]
+	}
+
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// scanTally builds the stats of a scan as its files are walked then scanned.
+type scanTally struct {
+	stats    models.ScanStats
+	ignored  map[string]struct{} // the paths counted as ignored, a directory counts once
+	baseline []byte              // the committed baseline met in an archive, nil if none
+}
+
+func newScanTally() *scanTally {
+	return &scanTally{ignored: map[string]struct{}{}}
+}
+
+func (t *scanTally) ignore(path string) {
+	if _, found := t.ignored[path]; found {
+		return
+	}
+	t.ignored[path] = struct{}{}
+	t.stats.IgnoredPaths++
+}
+
+// merge collects the findings of the scanned files in order. Files an ignore rule
+// matches are dropped, the rules may only be known once the files were scanned.
+func (t *scanTally) merge(results []fileResult, ignores *ignoreList) []models.Finding {
+	findings := []models.Finding{}
+	for _, result := range results {
+		if ignoredPath, ignored := ignores.match(result.path); ignored {
+			t.ignore(ignoredPath)
+			continue
+		}
+		if result.binary {
+			t.stats.BinaryFiles++
+			continue
+		}
+
+		t.stats.ScannedFiles++
+		findings = append(findings, result.findings...)
+	}
+
+	return findings
+}
@@ -0,0 +1,107 @@
+package gitscan
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/internal/config"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestIgnoreList(t *testing.T) {
+	ignores := newIgnoreList()
+	ignores.add([]byte(strings.Join([]string{
+		"# generated code",
+		"*.min.js",
+		"/build",
+		"docs/*.md",
+		"fixtures/",
+		"!keep.min.js",
+		"!vendor/",
+		`\#notes`,
+		"trailing   ",
+		"",
+	}, "\n")))
+
+	testCases := []struct {
+		path    string
+		ignored bool
+	}{
+		{path: "app.js", ignored: false},
+		{path: "static/app.min.js", ignored: true},
+		{path: "static/keep.min.js", ignored: false},
+		{path: "build/config.py", ignored: true},
+		{path: "src/build/config.py", ignored: false},
+		{path: "docs/index.md", ignored: true},
+		{path: "docs/api/index.md", ignored: false},
+		{path: "tests/fixtures/key.pem", ignored: true},
+		{path: "fixtures", ignored: false},
+		{path: "web/node_modules/left-pad/index.js", ignored: true},
+		{path: "vendor/github.com/lib/pq/conn.go", ignored: false},
+		{path: "#notes", ignored: true},
+		{path: "trailing", ignored: true},
+	}
+	for _, tc := range testCases {
+		_, ignored := ignores.match(tc.path)
+		require.Equal(t, tc.ignored, ignored, tc.path)
+	}
+
+	ignoredPath, _ := ignores.match("web/node_modules/left-pad/index.js")
+	require.Equal(t, "web/node_modules", ignoredPath)
+}
+
+func TestIgnoreListCannotReincludeUnderIgnoredDirectory(t *testing.T) {
+	ignores := newIgnoreList()
+	ignores.add([]byte("!node_modules/app/config.js\n"))
+
+	_, ignored := ignores.match("node_modules/app/config.js")
+	require.True(t, ignored)
+}
+
+func TestIsBinary(t *testing.T) {
+	require.False(t, isBinary([]byte("private_key = \"secret\"\n")))
+	require.True(t, isBinary([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")))
+	// git only looks at the start of the file
+	require.False(t, isBinary(append(bytes.Repeat([]byte("a"), sniffLength), 0)))
+}
+
+func TestScanDirSkipsFiles(t *testing.T) {
+	dir := t.TempDir()
+	secret := "private_key = \"secret\"\n"
+	files := map[string]string{
+		"config.py":                    secret,
+		"node_modules/lib/config.js":   secret,
+		"node_modules/other/config.js": secret,
+		"fixtures/key.py":              secret,
+		"logo.png":                     "\x89PNG\x00\x00" + secret,
+		"settings.py":                  "# settings\n" + secret,
+		"app.min.js":                   "var a=\"" + strings.Repeat("a", 100*1024) + "\",t=\"" + exampleGitHubToken + "\";",
+		scanIgnoreFile:                 "fixtures/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(dir, "passwd")))
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{MaxFileSize: 110 * 1024}).(*GitScan)
+
+	findings, stats, err := gitScan.scanDir(context.Background(), dir)
+	require.NoError(t, err)
+	paths := []string{}
+	for _, finding := range findings {
+		paths = append(paths, finding.Location.Path)
+	}
+	require.Equal(t, []string{"app.min.js", "config.py", "settings.py"}, paths)
+	require.Equal(t, &models.ScanStats{ScannedFiles: 4, IgnoredPaths: 2, BinaryFiles: 1}, stats)
+
+	gitScan.maxFileSize = 1024
+	_, stats, err = gitScan.scanDir(context.Background(), dir)
+	require.NoError(t, err)
+	require.Equal(t, &models.ScanStats{ScannedFiles: 3, IgnoredPaths: 2, BinaryFiles: 1, OversizedFiles: 1}, stats)
+}
@@ -0,0 +1,76 @@
+package gitscan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+// fingerprintFindings gives every finding of a file a fingerprint that identifies it across
+// scans: the hash of the rule, the path and the hash of the matched text. Line numbers are
+// left out so that a finding keeps its fingerprint when lines are added above it, and the
+// matched text is hashed so the secret cannot be read back from the fingerprint. Identical
+// matches of the same rule in a file are told apart by their order.
+func fingerprintFindings(findings []models.Finding, lines [][]byte) {
+	occurrences := map[string]int{}
+	for i := range findings {
+		finding := &findings[i]
+		matched := sha256.Sum256(matchedText(finding.Location.Position, lines))
+		key := finding.RuleID + "\x00" + finding.Location.Path + "\x00" + hex.EncodeToString(matched[:])
+		occurrence := occurrences[key]
+		occurrences[key]++
+		if occurrence > 0 {
+			key += "\x00" + strconv.Itoa(occurrence)
+		}
+
+		fingerprint := sha256.Sum256([]byte(key))
+		finding.Fingerprint = hex.EncodeToString(fingerprint[:])
+	}
+}
+
+// matchedText returns the text the position spans, lines are 1-based. Without columns
+// the whole lines are used.
+func matchedText(position models.Position, lines [][]byte) []byte {
+	beginLine, endLine := position.Begin.Line, position.End.Line
+	if endLine < beginLine {
+		endLine = beginLine
+	}
+	if beginLine < 1 || endLine > len(lines) {
+		return nil
+	}
+
+	text := []byte{}
+	for lineNumber := beginLine; lineNumber <= endLine; lineNumber++ {
+		line := lines[lineNumber-1]
+		start, end := 0, len(line)
+		if lineNumber == beginLine && position.Begin.Column > 0 {
+			start = byteOffset(line, position.Begin.Column)
+		}
+		if lineNumber == endLine && position.End.Column > 0 {
+			end = byteOffset(line, position.End.Column)
+		}
+		if lineNumber > beginLine {
+			text = append(text, '\n')
+		}
+		if start < end {
+			text = append(text, line[start:end]...)
+		}
+	}
+
+	return text
+}
+
+// byteOffset returns the offset of the 1-based character column in the line, the
+// length of the line if the column is past its end.
+func byteOffset(line []byte, column int) int {
+	offset := 0
+	for character := 1; character < column && offset < len(line); character++ {
+		_, size := utf8.DecodeRune(line[offset:])
+		offset += size
+	}
+
+	return offset
+}
@@ -0,0 +1,69 @@
+package gitscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestFingerprintIsStableAcrossEdits(t *testing.T) {
+	gitScan := &GitScan{registry: DefaultRegistry()}
+	scan := func(path string, content string) []models.Finding {
+		findings, err := gitScan.scanContent(path, []byte(content))
+		require.NoError(t, err)
+		return findingsOfRule(findings, "G101")
+	}
+
+	original := scan("config.py", "private_key = \"secret\"\n")
+	require.Len(t, original, 1)
+	require.Len(t, original[0].Fingerprint, 64)
+	require.NotContains(t, original[0].Fingerprint, "secret")
+
+	moved := scan("config.py", "# settings\n\nprivate_key = \"secret\"\n")
+	require.Equal(t, original[0].Fingerprint, moved[0].Fingerprint)
+
+	renamed := scan("settings.py", "private_key = \"secret\"\n")
+	require.NotEqual(t, original[0].Fingerprint, renamed[0].Fingerprint)
+
+	rotated := scan("config.py", "private_key = \"rotated\"\n")
+	require.NotEqual(t, original[0].Fingerprint, rotated[0].Fingerprint)
+
+	duplicated := scan("config.py", "private_key = \"secret\"\nprivate_key = \"secret\"\n")
+	require.Equal(t, original[0].Fingerprint, duplicated[0].Fingerprint)
+	require.NotEqual(t, duplicated[0].Fingerprint, duplicated[1].Fingerprint)
+}
+
+func TestMatchedText(t *testing.T) {
+	lines := [][]byte{[]byte("clé = \"abc\""), []byte("-----BEGIN KEY-----"), []byte("-----END KEY-----")}
+	testCases := []struct {
+		name     string
+		position models.Position
+		expected string
+	}{
+		{
+			name:     "columns count characters",
+			position: models.Position{Begin: models.Begin{Line: 1, Column: 8}, End: models.End{Line: 1, Column: 11}},
+			expected: "abc",
+		},
+		{
+			name:     "whole line without columns",
+			position: models.Position{Begin: models.Begin{Line: 1}},
+			expected: "clé = \"abc\"",
+		},
+		{
+			name:     "several lines",
+			position: models.Position{Begin: models.Begin{Line: 2, Column: 1}, End: models.End{Line: 3, Column: 18}},
+			expected: "-----BEGIN KEY-----\n-----END KEY-----",
+		},
+		{
+			name:     "out of range",
+			position: models.Position{Begin: models.Begin{Line: 4}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, string(matchedText(tc.position, lines)))
+		})
+	}
+}
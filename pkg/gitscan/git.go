@@ -0,0 +1,210 @@
+package gitscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// allowedGitProtocols restricts the transports git may use, see GIT_ALLOW_PROTOCOL in git(1).
+	allowedGitProtocols = "https:file"
+	// defaultTokenUsername is accepted along with a token by GitHub, GitLab and Bitbucket.
+	defaultTokenUsername = "x-access-token"
+)
+
+// GitFetcher fetches a ref with the git command line, over https or file://, or
+// over ssh with the deploy key of a private repository.
+// Only the tip commit is fetched unless the target asks for the history.
+type GitFetcher struct{}
+
+func NewGitFetcher() *GitFetcher {
+	return &GitFetcher{}
+}
+
+// Serves any host, which makes GitFetcher the provider of plain git hosts, Bitbucket included.
+func (f *GitFetcher) Serves(repositoryURL *url.URL) bool {
+	return true
+}
+
+func (f *GitFetcher) Fetch(ctx context.Context, target *Target, dir string) (*Checkout, error) {
+	log := zap.S()
+	repositoryURL := cloneURL(target)
+	ref := target.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if strings.HasPrefix(repositoryURL, "-") || strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid git url %s or ref %s", repositoryURL, ref)
+	}
+
+	auth, err := newGitAuth(repositoryURL, target.Credential, dir)
+	if err != nil {
+		log.Warnf("failed to set up credential, err: %+v", err)
+		return nil, err
+	}
+	defer auth.cleanup()
+
+	cloneDir, err := os.MkdirTemp(dir, "git-")
+	if err != nil {
+		log.Warnf("failed to create clone directory, err: %+v", err)
+		return nil, err
+	}
+
+	fetchArgs := []string{"fetch", "--quiet", "--no-tags"}
+	if !target.History {
+		fetchArgs = append(fetchArgs, "--depth=1")
+	}
+	fetchArgs = append(fetchArgs, auth.url, ref)
+
+	for _, step := range []struct {
+		env  []string
+		args []string
+	}{
+		{args: []string{"init", "--quiet"}},
+		{env: auth.env, args: fetchArgs},
+		{args: []string{"checkout", "--quiet", "--detach", "FETCH_HEAD"}},
+	} {
+		if _, err = runGitWithEnv(ctx, cloneDir, step.env, step.args...); err != nil {
+			log.Warnf("failed to fetch %s at %s, err: %+v", repositoryURL, ref, err)
+			os.RemoveAll(cloneDir)
+			return nil, err
+		}
+	}
+
+	output, err := runGit(ctx, cloneDir, "rev-parse", "HEAD")
+	if err != nil {
+		log.Warnf("failed to resolve commit sha, err: %+v", err)
+		os.RemoveAll(cloneDir)
+		return nil, err
+	}
+
+	return &Checkout{Dir: cloneDir, CommitSHA: strings.TrimSpace(string(output))}, nil
+}
+
+// cloneURL falls back to GitHub for targets queued before they carried their URL.
+func cloneURL(target *Target) string {
+	if target.URL != "" {
+		return target.URL
+	}
+
+	return fmt.Sprintf("https://github.com/%s/%s.git", target.Owner, target.Repo)
+}
+
+// gitAuth gives git access to a private repository. The secret is passed through
+// the environment, never on the command line nor in the URL, so it shows neither
+// in the process list nor in the error messages of git.
+type gitAuth struct {
+	url    string   // URL to fetch, ssh for deploy keys
+	env    []string // environment of the fetch
+	keyDir string   // holds the deploy key while fetching
+}
+
+func newGitAuth(repositoryURL string, credential *Credential, dir string) (*gitAuth, error) {
+	auth := &gitAuth{url: repositoryURL}
+	if credential == nil {
+		return auth, nil
+	}
+
+	parsedURL, err := url.Parse(repositoryURL)
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("credentials need an https repository url")
+	}
+
+	switch {
+	case credential.isToken():
+		username := credential.Username
+		if username == "" {
+			username = defaultTokenUsername
+		}
+		basicAuth := base64.StdEncoding.EncodeToString([]byte(username + ":" + credential.Secret))
+		// scoped to the host of the repository, redirects to another host do not get the token.
+		// git reads GIT_CONFIG_COUNT since 2.31, older versions fetch without the token
+		auth.env = []string{
+			"GIT_CONFIG_COUNT=1",
+			fmt.Sprintf("GIT_CONFIG_KEY_0=http.https://%s/.extraHeader", parsedURL.Host),
+			"GIT_CONFIG_VALUE_0=Authorization: Basic " + basicAuth,
+		}
+	case credential.isDeployKey():
+		keyDir, err := os.MkdirTemp(dir, "ssh-")
+		if err != nil {
+			return nil, err
+		}
+		auth.keyDir, err = filepath.Abs(keyDir)
+		if err != nil {
+			auth.cleanup()
+			return nil, err
+		}
+		keyPath := filepath.Join(auth.keyDir, "id")
+		if err = os.WriteFile(keyPath, []byte(credential.Secret), 0o600); err != nil {
+			auth.cleanup()
+			return nil, err
+		}
+
+		auth.url = fmt.Sprintf("ssh://git@%s/%s.git", parsedURL.Hostname(),
+			strings.TrimSuffix(strings.Trim(parsedURL.Path, "/"), ".git"))
+		// the host key is trusted on first use, the known hosts live as long as the fetch
+		auth.env = []string{
+			"GIT_ALLOW_PROTOCOL=ssh",
+			fmt.Sprintf("GIT_SSH_COMMAND=ssh -F /dev/null -i %s -o IdentitiesOnly=yes -o BatchMode=yes "+
+				"-o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=%s",
+				shellQuote(keyPath), shellQuote(filepath.Join(auth.keyDir, "known_hosts"))),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported credential kind %s", credential.Kind)
+	}
+
+	return auth, nil
+}
+
+func (a *gitAuth) cleanup() {
+	if a.keyDir != "" {
+		os.RemoveAll(a.keyDir)
+	}
+}
+
+// shellQuote quotes a word for sh, git runs GIT_SSH_COMMAND with the shell.
+func shellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+func gitCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_ALLOW_PROTOCOL="+allowedGitProtocols,
+	)
+
+	return cmd
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	return runGitWithEnv(ctx, dir, nil, args...)
+}
+
+// runGitWithEnv runs git with extra environment variables, they override the default ones.
+func runGitWithEnv(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := gitCommand(ctx, dir, args...)
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		// git was killed, its error would only say so
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, gitError(fmt.Errorf("git %s: %w", args[0], err), strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
@@ -0,0 +1,117 @@
+package gitscan
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+	"go.uber.org/zap"
+)
+
+const githubHost = "github.com"
+
+// GitHubProvider downloads the tarball GitHub builds for a commit, it has no history.
+type GitHubProvider struct {
+	githubClient *github.Client
+	httpClient   *http.Client
+	limits       ExtractLimits
+}
+
+func NewGitHubProvider(httpClient *http.Client, limits ExtractLimits) *GitHubProvider {
+	return &GitHubProvider{
+		githubClient: github.NewClient(httpClient),
+		httpClient:   httpClient,
+		limits:       limits,
+	}
+}
+
+func (f *GitHubProvider) Serves(repositoryURL *url.URL) bool {
+	return strings.EqualFold(repositoryURL.Host, githubHost)
+}
+
+func (f *GitHubProvider) Fetch(ctx context.Context, target *Target, dir string) (*Checkout, error) {
+	return extractArchive(ctx, f, target, dir, f.limits)
+}
+
+func (f *GitHubProvider) OpenArchive(ctx context.Context, target *Target) (*Archive, error) {
+	log := zap.S()
+	ownerName, repoName := target.Owner, target.Repo
+
+	// resolve the ref first so the tarball and the reported commit are the same even if the ref moves meanwhile
+	commitSHA, err := f.resolveCommitSHA(ctx, target)
+	if err != nil {
+		log.Warnf("failed to resolve commit sha, err: %+v", err)
+		return nil, err
+	}
+	log.Infof("resolved ref %s to commit %s", target.Ref, commitSHA)
+
+	// the link is signed, downloading the tarball of a private repository needs no token
+	archiveURL, _, err := f.clientFor(target).Repositories.GetArchiveLink(
+		ctx, ownerName, repoName, github.Tarball,
+		&github.RepositoryContentGetOptions{Ref: commitSHA}, true,
+	)
+	if err != nil {
+		log.Warnf("failed to get archive link, err: %+v", err)
+		return nil, githubError(err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, archiveURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := downloadArchive(f.httpClient, request)
+	if err != nil {
+		log.Warnf("failed to download tarball, err: %+v", err)
+		return nil, err
+	}
+
+	return &Archive{ReadCloser: body, CommitSHA: commitSHA}, nil
+}
+
+func (f *GitHubProvider) resolveCommitSHA(ctx context.Context, target *Target) (string, error) {
+	ref := target.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	sha, _, err := f.clientFor(target).Repositories.GetCommitSHA1(ctx, target.Owner, target.Repo, ref, "")
+	if err != nil {
+		return "", githubError(err)
+	}
+
+	return sha, nil
+}
+
+// clientFor authenticates the API calls with the token of private repositories.
+func (f *GitHubProvider) clientFor(target *Target) *github.Client {
+	if !target.Credential.isToken() {
+		return f.githubClient
+	}
+
+	client := github.NewClient(&http.Client{
+		Transport: &tokenTransport{token: target.Credential.Secret, base: f.httpClient.Transport},
+		Timeout:   f.httpClient.Timeout,
+	})
+	client.BaseURL = f.githubClient.BaseURL
+
+	return client
+}
+
+// tokenTransport sends a bearer token with every request.
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	request = request.Clone(request.Context())
+	request.Header.Set("Authorization", "Bearer "+t.token)
+	return base.RoundTrip(request)
+}
@@ -0,0 +1,156 @@
+package gitscan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const gitlabHost = "gitlab.com"
+
+// GitLabProvider downloads the archives of gitlab.com and self-managed GitLab
+// instances through the REST API v4, it has no history.
+type GitLabProvider struct {
+	httpClient *http.Client
+	hosts      map[string]bool
+	limits     ExtractLimits
+}
+
+// NewGitLabProvider serves gitlab.com and the hosts of self-managed instances.
+func NewGitLabProvider(httpClient *http.Client, hosts []string, limits ExtractLimits) *GitLabProvider {
+	provider := &GitLabProvider{
+		httpClient: httpClient,
+		hosts:      map[string]bool{gitlabHost: true},
+		limits:     limits,
+	}
+	for _, host := range hosts {
+		provider.hosts[strings.ToLower(host)] = true
+	}
+
+	return provider
+}
+
+func (f *GitLabProvider) Serves(repositoryURL *url.URL) bool {
+	return f.hosts[strings.ToLower(repositoryURL.Host)]
+}
+
+func (f *GitLabProvider) Fetch(ctx context.Context, target *Target, dir string) (*Checkout, error) {
+	return extractArchive(ctx, f, target, dir, f.limits)
+}
+
+func (f *GitLabProvider) OpenArchive(ctx context.Context, target *Target) (*Archive, error) {
+	log := zap.S()
+	projectURL, err := f.projectURL(target)
+	if err != nil {
+		return nil, err
+	}
+
+	commitSHA, err := f.resolveCommitSHA(ctx, projectURL, target)
+	if err != nil {
+		log.Warnf("failed to resolve commit sha, err: %+v", err)
+		return nil, err
+	}
+	log.Infof("resolved ref %s to commit %s", target.Ref, commitSHA)
+
+	request, err := f.newRequest(ctx,
+		fmt.Sprintf("%s/repository/archive.tar.gz?sha=%s", projectURL, url.QueryEscape(commitSHA)), target)
+	if err != nil {
+		return nil, err
+	}
+	body, err := downloadArchive(f.httpClient, request)
+	if err != nil {
+		log.Warnf("failed to download archive, err: %+v", err)
+		return nil, err
+	}
+
+	return &Archive{ReadCloser: body, CommitSHA: commitSHA}, nil
+}
+
+// projectURL is the API endpoint of the project, the project is identified by its URL encoded path.
+func (f *GitLabProvider) projectURL(target *Target) (string, error) {
+	repositoryURL, err := url.Parse(cloneURL(target))
+	if err != nil {
+		return "", err
+	}
+
+	projectPath := fmt.Sprintf("%s/%s", target.Owner, target.Repo)
+	return fmt.Sprintf("%s://%s/api/v4/projects/%s", repositoryURL.Scheme, repositoryURL.Host, url.PathEscape(projectPath)), nil
+}
+
+// resolveCommitSHA resolves the ref, the default branch of the project when empty, to a commit.
+func (f *GitLabProvider) resolveCommitSHA(ctx context.Context, projectURL string, target *Target) (string, error) {
+	ref := target.Ref
+	projectFound := false
+	if ref == "" {
+		var project struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := f.getJSON(ctx, projectURL, target, &project); err != nil {
+			return "", err
+		}
+		if project.DefaultBranch == "" {
+			return "", errors.New("project has no default branch")
+		}
+		ref = project.DefaultBranch
+		projectFound = true
+	}
+
+	var commit struct {
+		ID string `json:"id"`
+	}
+	err := f.getJSON(ctx, fmt.Sprintf("%s/repository/commits/%s", projectURL, url.PathEscape(ref)), target, &commit)
+	if errors.Is(err, ErrRepositoryNotFound) {
+		// GitLab answers 404 for an unknown ref as for an unknown project, asking for the project tells them apart
+		if !projectFound {
+			if projectErr := f.getJSON(ctx, projectURL, target, &struct{}{}); projectErr != nil {
+				return "", projectErr
+			}
+		}
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, ref)
+	}
+	if err != nil {
+		return "", err
+	}
+	if commit.ID == "" {
+		return "", fmt.Errorf("commit %s has no id", ref)
+	}
+
+	return commit.ID, nil
+}
+
+func (f *GitLabProvider) getJSON(ctx context.Context, endpoint string, target *Target, v interface{}) error {
+	request, err := f.newRequest(ctx, endpoint, target)
+	if err != nil {
+		return err
+	}
+	resp, err := f.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return httpStatusError(request, resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// newRequest authenticates the request with the token of private projects.
+func (f *GitLabProvider) newRequest(ctx context.Context, endpoint string, target *Target) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if target.Credential.isToken() {
+		request.Header.Set("PRIVATE-TOKEN", target.Credential.Secret)
+	}
+
+	return request, nil
+}
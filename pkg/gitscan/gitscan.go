@@ -1,260 +1,269 @@
 package gitscan
 
 import (
-	"archive/tar"
-	"bufio"
-	"compress/gzip"
+	"bytes"
 	"context"
-	"errors"
-	"fmt"
-	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"path"
 	"path/filepath"
-	"strings"
 	"time"
 
-	"github.com/google/go-github/v47/github"
+	"github.com/vumanhcuongit/scan/internal/config"
 	"github.com/vumanhcuongit/scan/pkg/models"
 	"go.uber.org/zap"
 )
 
 //go:generate mockgen -source=gitscan.go -destination=igitscan.mock.go -package=gitscan
 
-const (
-	ruleID                    = "G101"
-	typeSast                  = "sast"
-	exampleFindingDescription = "Potential hardcoded credentials"
-	exampleFindingSeverity    = "HIGH"
-	secretPrivateKey          = "private_key"
-	secretPublicKey           = "public_key"
-)
-
 type IGitScan interface {
-	Scan(
-		ctx context.Context,
-		ownerName string,
-		repoName string,
-	) ([]models.Finding, error)
+	Scan(ctx context.Context, target *Target) (*Result, error)
+}
+
+// Target identifies the source code to scan.
+type Target struct {
+	ScanID  int64  // names the workspace of the scan
+	URL     string // clone URL of the repository
+	Owner   string
+	Repo    string
+	Ref     string // branch, tag or commit SHA, the default branch if empty
+	History bool   // scan the lines added by every commit of the ref instead of its tip
+
+	Credential *Credential // authenticates the fetch of a private repository
+}
+
+// Credential is the decrypted credential of a private repository.
+type Credential struct {
+	Kind     string // models.CredentialKindToken or models.CredentialKindDeployKey
+	Username string // user of the token over https
+	Secret   string
+}
+
+// String keeps the secret out of the logs.
+func (c *Credential) String() string {
+	return c.Kind + " credential"
+}
+
+func (c *Credential) isToken() bool {
+	return c != nil && c.Kind == models.CredentialKindToken
+}
+
+func (c *Credential) isDeployKey() bool {
+	return c != nil && c.Kind == models.CredentialKindDeployKey
+}
+
+// Result is the outcome of scanning a target.
+type Result struct {
+	CommitSHA string // commit the ref resolved to, the findings belong to this commit
+	Findings  []models.Finding
+	Stats     *models.ScanStats // files scanned and skipped, not counted when the history is scanned
 }
 
 type GitScan struct {
-	sourceCodesDir string // directory contains repository's code
-	githubClient   *github.Client
-	httpClient     *http.Client
+	sourceCodesDir string // directory contains the workspace of every scan
+	registry       *Registry
+	providers      []SourceProvider // the first provider serving the repository fetches it
+	gitFetcher     *GitFetcher
+	cloneOnly      bool // fetch every repository with git, even from hosts providing archives
+	stream         bool // scan archives as they download instead of extracting them
+	limits         ExtractLimits
+	concurrency    int   // files of a scan scanned in parallel
+	maxFileSize    int64 // larger files are skipped
 }
 
-func NewGitScan(sourcesCodeDir string) IGitScan {
-	httpClient := &http.Client{Timeout: 2 * time.Minute}
-	githubClient := github.NewClient(httpClient)
+func NewGitScan(sourcesCodeDir string, registry *Registry, cfg *config.ScannerConfig) IGitScan {
+	httpClient := newHTTPClient()
+	gitFetcher := NewGitFetcher()
+	limits := newExtractLimits(&cfg.Archive)
+	maxFileSize := cfg.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = defaultMaxFileSize
+	}
 	return &GitScan{
 		sourceCodesDir: sourcesCodeDir,
-		githubClient:   githubClient,
-		httpClient:     httpClient,
+		registry:       registry,
+		providers:      newSourceProviders(httpClient, cfg, gitFetcher, limits),
+		gitFetcher:     gitFetcher,
+		cloneOnly:      cfg.Fetcher == FetcherGit,
+		stream:         cfg.Stream,
+		limits:         limits,
+		concurrency:    newConcurrency(cfg.Concurrency),
+		maxFileSize:    maxFileSize,
 	}
 }
 
-func (g *GitScan) Scan(
-	ctx context.Context,
-	ownerName string,
-	repoName string,
-) ([]models.Finding, error) {
+// newHTTPClient bounds the calls to the APIs of the hosts. Archive downloads drop the
+// total timeout, only connecting and waiting for the response are bounded for them.
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	transport.ResponseHeaderTimeout = time.Minute
+
+	return &http.Client{Transport: transport, Timeout: 2 * time.Minute}
+}
+
+func (g *GitScan) Scan(ctx context.Context, target *Target) (*Result, error) {
 	log := zap.S()
-	log.Infof("starting to scan repository, owner name %s, repo name %s", ownerName, repoName)
+	log.Infof("starting to scan repository, owner name %s, repo name %s, ref %s, history %t",
+		target.Owner, target.Repo, target.Ref, target.History)
+
+	fetcher := g.fetcherFor(target)
+	if archiveProvider, ok := fetcher.(ArchiveProvider); ok && g.stream {
+		return g.scanStream(ctx, archiveProvider, target)
+	}
 
-	url, _, err := g.githubClient.Repositories.GetArchiveLink(
-		ctx, ownerName, repoName, github.Tarball,
-		&github.RepositoryContentGetOptions{}, true,
-	)
+	workspace, err := newWorkspace(g.sourceCodesDir, target.ScanID)
 	if err != nil {
-		log.Warnf("failed to get archive link, err: %+v", err)
+		log.Warnf("failed to create workspace, err: %+v", err)
 		return nil, err
 	}
+	// removed whether the scan succeeds, fails or panics
+	defer os.RemoveAll(workspace)
 
-	err = g.downloadAndUntar(ctx, url.String(), g.sourceCodesDir)
+	checkout, err := fetcher.Fetch(ctx, target, workspace)
 	if err != nil {
-		log.Warnf("failed to download tarball, err: %+v", err)
+		log.Warnf("failed to fetch source code, err: %+v", err)
 		return nil, err
 	}
 
-	repoFolderName := ""
-	err = filepath.Walk(g.sourceCodesDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), fmt.Sprintf("%s-%s", ownerName, repoName)) {
-				log.Infof("found repo directory: %s", info.Name())
-				repoFolderName = info.Name()
-				return io.EOF
-			}
-			return nil
-		}
-
-		return nil
-	})
-	if err != nil && err != io.EOF {
-		log.Warnf("failed to read file, err: %+v", err)
+	result := &Result{CommitSHA: checkout.CommitSHA}
+	if target.History {
+		result.Findings, err = g.scanHistory(ctx, checkout.Dir)
+	} else {
+		result.Findings, result.Stats, err = g.scanDir(ctx, checkout.Dir)
+	}
+	if err != nil {
+		log.Warnf("failed to scan source code, err: %+v", err)
 		return nil, err
 	}
 
-	if repoFolderName == "" {
-		log.Warnf("empty repo directory")
-		return nil, errors.New("empty repo directory")
+	return result, nil
+}
+
+// fetcherFor picks the provider serving the repository, only a clone carries the
+// history and deploy keys only give access over ssh.
+func (g *GitScan) fetcherFor(target *Target) Fetcher {
+	if target.History || g.cloneOnly || target.Credential.isDeployKey() {
+		return g.gitFetcher
 	}
 
-	repoDir := path.Join(g.sourceCodesDir, repoFolderName)
-	defer os.RemoveAll(repoDir)
-	findings := []models.Finding{}
-	err = filepath.Walk(repoDir, func(path string, info os.FileInfo, err error) error {
+	repositoryURL, err := url.Parse(cloneURL(target))
+	if err != nil {
+		return g.gitFetcher
+	}
+	for _, provider := range g.providers {
+		if provider.Serves(repositoryURL) {
+			return provider
+		}
+	}
+
+	return g.gitFetcher
+}
+
+// scanDir scans the files of the source tree rooted at repoDir, the findings are in
+// the order the walk visits the files. Ignored paths, symlinks and files over the
+// maximum size are skipped without being read. Findings the committed baseline
+// accepts are marked baselined.
+func (g *GitScan) scanDir(ctx context.Context, repoDir string) ([]models.Finding, *models.ScanStats, error) {
+	ignores := loadIgnoreList(repoDir, g.maxFileSize)
+	tally := newScanTally()
+	pool := g.newScanPool(ctx)
+	err := filepath.Walk(repoDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
+		// stop walking a cancelled scan, not only reading its files
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if path == repoDir {
 			return nil
 		}
 
-		f, err := os.Open(path)
+		relativePath, err := filepath.Rel(repoDir, path)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
-		defer func() {
-			if err = f.Close(); err != nil {
-				log.Fatal(err)
+		relativePath = filepath.ToSlash(relativePath)
+		if info.IsDir() {
+			// the clone's metadata is not part of the source tree
+			if info.Name() == ".git" {
+				return filepath.SkipDir
 			}
-		}()
-		s := bufio.NewScanner(f)
-
-		lineNumber := 0
-		for s.Scan() {
-			lineNumber++
-			containPrivateKey := strings.HasPrefix(s.Text(), secretPrivateKey)
-			containPublicKey := strings.HasPrefix(s.Text(), secretPublicKey)
-			if !containPrivateKey && !containPublicKey {
-				continue
+			if ignores.ignored(relativePath, true) {
+				tally.ignore(relativePath)
+				return filepath.SkipDir
 			}
-
-			extractedPath := strings.Join(strings.Split(path, "/")[2:], "/")
-			findings = append(findings, models.Finding{
-				Type:   typeSast,
-				RuleID: ruleID,
-				Location: models.Location{
-					Path: extractedPath,
-					Position: models.Position{
-						Begin: models.Begin{
-							Line: lineNumber,
-						},
-					},
-				},
-				Metadata: models.Metadata{
-					Description: exampleFindingDescription,
-					Severity:    exampleFindingSeverity,
-				},
-			})
+			return nil
 		}
-		err = s.Err()
-		if err != nil {
-			return err
+
+		switch {
+		case !info.Mode().IsRegular():
+			return nil
+		case relativePath == models.BaselineFile:
+			// lists fingerprints, not source code
+			return nil
+		case ignores.ignored(relativePath, false):
+			tally.ignore(relativePath)
+			return nil
+		case info.Size() > g.maxFileSize:
+			tally.stats.OversizedFiles++
+			return nil
 		}
 
-		return nil
+		return pool.submit(relativePath, func() ([]byte, error) {
+			return ioutil.ReadFile(path)
+		})
 	})
+	results, poolErr := pool.wait()
 	if err != nil {
-		log.Warnf("failed to scan source code, err: %+v", err)
-		return nil, err
+		return nil, nil, err
 	}
-
-	return findings, nil
-}
-
-func (g *GitScan) downloadAndUntar(ctx context.Context, downloadURL string, destPath string) error {
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
-	if err != nil {
-		return err
-	}
-	resp, err := g.httpClient.Do(request)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return errors.New("received non 200 response code")
-	}
-
-	_, err = g.untarWithIOReader(ctx, resp.Body, destPath)
-	if err != nil {
-		return err
+	if poolErr != nil {
+		return nil, nil, poolErr
 	}
 
-	return nil
+	findings := tally.merge(results, ignores)
+	loadBaseline(repoDir).Apply(findings)
+	return findings, &tally.stats, nil
 }
 
-func (g *GitScan) untarWithIOReader(
-	ctx context.Context,
-	tarFile io.ReadCloser,
-	destination string,
-) (string, error) {
-	directory := ""
-
-	gz, err := gzip.NewReader(tarFile)
-	if err != nil {
-		return directory, err
-	}
-	defer gz.Close()
-	tr := tar.NewReader(gz)
-
-	absPath, err := filepath.Abs(destination)
-	if err != nil {
-		return directory, err
-	}
-
-	// untar each segment
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return directory, err
-		}
-		// determine proper file path info
-		finfo := hdr.FileInfo()
-		fileName := hdr.Name
-		absFileName := filepath.Join(absPath, fileName)
-		if finfo.Mode().IsDir() {
-			if err = os.MkdirAll(absFileName, os.ModePerm); err != nil {
-				return directory, err
-			}
-
-			continue
-		}
-
-		if directory == "" && filepath.Base(fileName) == "package.json" {
-			directory = filepath.Dir(absFileName)
-		}
-
-		// Creating the files in the target directory
-		if err = os.MkdirAll(filepath.Dir(absFileName), os.ModePerm); err != nil {
-			return directory, err
+// scanContent runs the line rules over every line of the content, then the file rules
+// over the whole content. Findings a scan:ignore comment covers are marked suppressed.
+func (g *GitScan) scanContent(path string, content []byte) ([]models.Finding, error) {
+	findings := []models.Finding{}
+	suppressions := map[int]*suppression{}
+	lines := [][]byte{}
+	lineNumber := 0
+	// split by hand, bufio.Scanner fails on lines over 64KB like those of minified files
+	for rest := content; len(rest) > 0; {
+		line := rest
+		if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+			line, rest = rest[:end], rest[end+1:]
+		} else {
+			rest = nil
 		}
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		lines = append(lines, line)
 
-		// create new file with original file mode
-		file, err := os.OpenFile(
-			absFileName,
-			os.O_RDWR|os.O_CREATE|os.O_TRUNC,
-			finfo.Mode().Perm(),
-		)
-		if err != nil {
-			return directory, err
+		lineNumber++
+		if bytes.Contains(line, []byte(suppressionMarker)) {
+			if s := parseSuppression(string(line)); s != nil {
+				suppressions[lineNumber] = s
+			}
 		}
-		defer file.Close()
-
-		_, cpErr := io.Copy(file, tr)
-		if cpErr != nil && cpErr != io.EOF {
-			return directory, cpErr
+		for _, finding := range g.registry.Match(path, string(line)) {
+			finding.Location.Position.Begin.Line = lineNumber
+			finding.Location.Position.End.Line = lineNumber
+			findings = append(findings, finding)
 		}
 	}
-	return directory, nil
+
+	findings = append(findings, g.registry.MatchFile(path, content)...)
+	applySuppressions(findings, suppressions)
+	fingerprintFindings(findings, lines)
+	return findings, nil
 }
@@ -1,11 +1,20 @@
 package gitscan
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/internal/config"
+	"github.com/vumanhcuongit/scan/pkg/models"
 	"go.uber.org/zap"
 )
 
@@ -20,9 +29,123 @@ func TestScanSourceCode(t *testing.T) {
 
 	sourceCodesDir := "./source_codes"
 	os.RemoveAll(sourceCodesDir)
-	gitScanSrv := NewGitScan(sourceCodesDir)
-	findings, err := gitScanSrv.Scan(ctx, "vumanhcuongit", "workshop")
+	gitScanSrv := NewGitScan(sourceCodesDir, DefaultRegistry(), &config.ScannerConfig{})
+	result, err := gitScanSrv.Scan(ctx, &Target{Owner: "vumanhcuongit", Repo: "workshop"})
 	require.NoError(t, err)
-	require.Equal(t, 3, len(findings))
+	require.Equal(t, 3, len(result.Findings))
+	require.Len(t, result.CommitSHA, 40)
 	os.RemoveAll(sourceCodesDir)
 }
+
+const exampleCommitSHA = "2d4a8f3c9e1b7a6d5c4f3e2a1b0c9d8e7f6a5b4c"
+
+func TestScanWithRef(t *testing.T) {
+	archive := newTarball(t, map[string]string{
+		"acme-app-2d4a8f3/config.py": "private_key = \"secret\"\n",
+		"acme-app-2d4a8f3/README.md": "# app\n",
+	})
+	requestedRefs := []string{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/app/commits/release/v1":
+			requestedRefs = append(requestedRefs, "release/v1")
+			_, _ = w.Write([]byte(exampleCommitSHA))
+		case "/repos/acme/app/commits/HEAD":
+			requestedRefs = append(requestedRefs, "HEAD")
+			_, _ = w.Write([]byte(exampleCommitSHA))
+		case "/repos/acme/app/tarball/" + exampleCommitSHA:
+			http.Redirect(w, r, server.URL+"/archive.tar.gz", http.StatusFound)
+		case "/archive.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gitScan := newTestGitScan(t, server.URL)
+	result, err := gitScan.Scan(context.Background(), &Target{Owner: "acme", Repo: "app", Ref: "release/v1"})
+	require.NoError(t, err)
+	require.Equal(t, exampleCommitSHA, result.CommitSHA)
+	require.Len(t, result.Findings, 1)
+	require.Equal(t, "config.py", result.Findings[0].Location.Path)
+
+	// the default branch is resolved through HEAD
+	result, err = gitScan.Scan(context.Background(), &Target{Owner: "acme", Repo: "app"})
+	require.NoError(t, err)
+	require.Equal(t, exampleCommitSHA, result.CommitSHA)
+	require.Equal(t, []string{"release/v1", "HEAD"}, requestedRefs)
+
+	_, err = gitScan.Scan(context.Background(), &Target{Owner: "acme", Repo: "app", Ref: "missing"})
+	require.Error(t, err)
+}
+
+func TestScanPrivateGitHubRepository(t *testing.T) {
+	archive := newTarball(t, map[string]string{"acme-private-2d4a8f3/config.py": "private_key = \"secret\"\n"})
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/archive.tar.gz" {
+			// the archive link is signed, the token must not leak to the storage host
+			require.Empty(t, r.Header.Get("Authorization"))
+			_, _ = w.Write(archive)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer ghp_secret" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/acme/private/commits/HEAD":
+			_, _ = w.Write([]byte(exampleCommitSHA))
+		case "/repos/acme/private/tarball/" + exampleCommitSHA:
+			http.Redirect(w, r, server.URL+"/archive.tar.gz", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gitScan := newTestGitScan(t, server.URL)
+	_, err := gitScan.Scan(context.Background(), &Target{Owner: "acme", Repo: "private"})
+	require.Error(t, err)
+
+	credential := &Credential{Kind: models.CredentialKindToken, Secret: "ghp_secret"}
+	result, err := gitScan.Scan(context.Background(), &Target{Owner: "acme", Repo: "private", Credential: credential})
+	require.NoError(t, err)
+	require.Equal(t, exampleCommitSHA, result.CommitSHA)
+	require.Len(t, result.Findings, 1)
+	require.NotContains(t, fmt.Sprintf("%+v", Target{Credential: credential}), "ghp_secret")
+}
+
+func newTestGitScan(t *testing.T, serverURL string) *GitScan {
+	t.Helper()
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{}).(*GitScan)
+	baseURL, err := url.Parse(serverURL + "/")
+	require.NoError(t, err)
+	gitScan.providers[0].(*GitHubProvider).githubClient.BaseURL = baseURL
+
+	return gitScan
+}
+
+// newTarball builds a gzipped tarball the way GitHub serves repository archives.
+func newTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
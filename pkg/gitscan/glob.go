@@ -0,0 +1,109 @@
+package gitscan
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// glob matches slash separated paths relative to the repository root.
+// A pattern without a slash matches the file name in any directory, otherwise
+// it is matched against the whole path. `*` and `?` never cross a `/`, while
+// `**` matches any number of directories.
+type glob struct {
+	pattern  string
+	baseOnly bool
+	re       *regexp.Regexp
+}
+
+func compileGlob(pattern string) (*glob, error) {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty glob pattern")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" also matches the current directory
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid glob pattern %q: unterminated character class", pattern)
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+
+	return &glob{
+		pattern:  pattern,
+		baseOnly: !strings.Contains(pattern, "/"),
+		re:       re,
+	}, nil
+}
+
+func (g *glob) Match(filePath string) bool {
+	if g.baseOnly {
+		return g.re.MatchString(path.Base(filePath))
+	}
+	return g.re.MatchString(filePath)
+}
+
+func compileGlobs(patterns []string) ([]*glob, error) {
+	globs := make([]*glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+
+	return globs, nil
+}
+
+func matchAnyGlob(globs []*glob, filePath string) bool {
+	for _, g := range globs {
+		if g.Match(filePath) {
+			return true
+		}
+	}
+
+	return false
+}
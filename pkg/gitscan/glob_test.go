@@ -0,0 +1,50 @@
+package gitscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobMatch(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{pattern: "*.go", path: "main.go", expected: true},
+		{pattern: "*.go", path: "cmd/app/main.go", expected: true},
+		{pattern: "*.go", path: "main.go.txt", expected: false},
+		{pattern: "cmd/*.go", path: "cmd/main.go", expected: true},
+		{pattern: "cmd/*.go", path: "cmd/app/main.go", expected: false},
+		{pattern: "**/*.go", path: "main.go", expected: true},
+		{pattern: "**/*.go", path: "cmd/app/main.go", expected: true},
+		{pattern: "vendor/**", path: "vendor/github.com/pkg/errors.go", expected: true},
+		{pattern: "vendor/**", path: "pkg/vendor/errors.go", expected: false},
+		{pattern: "**/testdata/**", path: "pkg/gitscan/testdata/key.pem", expected: true},
+		{pattern: "config/**/*.yaml", path: "config/app.yaml", expected: true},
+		{pattern: "config/**/*.yaml", path: "config/env/prod/app.yaml", expected: true},
+		{pattern: "?.txt", path: "a.txt", expected: true},
+		{pattern: "?.txt", path: "ab.txt", expected: false},
+		{pattern: "[ab].txt", path: "b.txt", expected: true},
+		{pattern: "[!ab].txt", path: "b.txt", expected: false},
+		{pattern: "./main.go", path: "main.go", expected: true},
+		{pattern: "file\\*.txt", path: "file*.txt", expected: true},
+		{pattern: "file\\*.txt", path: "fileA.txt", expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.path, func(t *testing.T) {
+			g, err := compileGlob(tc.pattern)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, g.Match(tc.path))
+		})
+	}
+}
+
+func TestCompileGlobWithInvalidPattern(t *testing.T) {
+	_, err := compileGlob("")
+	require.Error(t, err)
+
+	_, err = compileGlob("[abc")
+	require.Error(t, err)
+}
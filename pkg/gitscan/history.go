@@ -0,0 +1,225 @@
+package gitscan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+// historyFormat starts every commit of the log with a NUL byte, which no line of a patch can start with.
+const historyFormat = "--format=%x00%H%x00%an%x00%ae%x00%aI"
+
+// scanHistory runs the rules over the lines added by every commit reachable from HEAD,
+// so secrets deleted since are still reported along with the commit that introduced them.
+// Paths ignored by the .scanignore of HEAD are skipped in every commit and the baseline
+// of HEAD applies to the findings of every commit.
+func (g *GitScan) scanHistory(ctx context.Context, dir string) ([]models.Finding, error) {
+	ignores := loadIgnoreList(dir, g.maxFileSize)
+	var stderr bytes.Buffer
+	cmd := gitCommand(ctx, dir,
+		"-c", "core.quotePath=false",
+		"log", "-p", "--unified=0", "--no-color", "--no-ext-diff", historyFormat, "HEAD",
+	)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	findings, err := g.scanPatches(stdout, ignores)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	if err = cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("git log: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	loadBaseline(dir).Apply(findings)
+	return findings, nil
+}
+
+// historyHunk is the block of lines a commit added at the same place of a file.
+type historyHunk struct {
+	path      string
+	startLine int
+	lines     []string
+}
+
+// scanPatches parses the output of git log -p --unified=0 in historyFormat.
+// The hunk headers tell how many removed and added lines follow, which is what
+// tells an added line apart from a header even if it looks like one.
+func (g *GitScan) scanPatches(r io.Reader, ignores *ignoreList) ([]models.Finding, error) {
+	findings := []models.Finding{}
+	reader := bufio.NewReader(r)
+
+	var commit *models.Commit
+	var hunk *historyHunk
+	path := ""
+	removedLeft, addedLeft := 0, 0
+	flush := func() error {
+		if hunk == nil || len(hunk.lines) == 0 {
+			hunk = nil
+			return nil
+		}
+		hunkFindings, err := g.scanHunk(hunk, commit)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, hunkFindings...)
+		hunk = nil
+		return nil
+	}
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+		if line == "" && readErr == io.EOF {
+			break
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if removedLeft > 0 || addedLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "-") && removedLeft > 0:
+				removedLeft--
+				continue
+			case strings.HasPrefix(line, "+") && addedLeft > 0:
+				addedLeft--
+				if hunk != nil {
+					hunk.lines = append(hunk.lines, line[1:])
+				}
+				continue
+			case strings.HasPrefix(line, `\`):
+				continue
+			}
+			// the hunk ended earlier than announced, read the line as a header
+			removedLeft, addedLeft = 0, 0
+		}
+
+		switch {
+		case strings.HasPrefix(line, "\x00"):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			commit = parseHistoryCommit(line)
+			path = ""
+		case strings.HasPrefix(line, "diff --git "):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			path = ""
+		case strings.HasPrefix(line, "+++ "):
+			path = parseHistoryPath(strings.TrimPrefix(line, "+++ "))
+		case strings.HasPrefix(line, "@@ "):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			var startLine int
+			removedLeft, startLine, addedLeft = parseHunkHeader(line)
+			_, ignored := ignores.match(path)
+			if path != "" && path != models.BaselineFile && addedLeft > 0 && !ignored {
+				hunk = &historyHunk{path: path, startLine: startLine}
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return findings, nil
+}
+
+// scanHunk scans the added lines as if they were a file, then moves the findings to where the lines were added.
+func (g *GitScan) scanHunk(hunk *historyHunk, commit *models.Commit) ([]models.Finding, error) {
+	content := strings.Join(hunk.lines, "\n") + "\n"
+	findings, err := g.scanContent(hunk.path, []byte(content))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range findings {
+		findings[i].Location.Position.Begin.Line += hunk.startLine - 1
+		if findings[i].Location.Position.End.Line > 0 {
+			findings[i].Location.Position.End.Line += hunk.startLine - 1
+		}
+		findings[i].Commit = commit
+	}
+
+	return findings, nil
+}
+
+func parseHistoryCommit(line string) *models.Commit {
+	fields := strings.Split(strings.TrimPrefix(line, "\x00"), "\x00")
+	commit := &models.Commit{SHA: fields[0]}
+	if len(fields) > 1 {
+		commit.Author = fields[1]
+	}
+	if len(fields) > 2 {
+		commit.Email = fields[2]
+	}
+	if len(fields) > 3 {
+		if date, err := time.Parse(time.RFC3339, fields[3]); err == nil {
+			commit.Date = date
+		}
+	}
+
+	return commit
+}
+
+// parseHistoryPath returns the path of the new side of a file diff, empty when the file was deleted.
+func parseHistoryPath(path string) string {
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+	}
+
+	return strings.TrimPrefix(path, "b/")
+}
+
+// parseHunkHeader reads "@@ -start,count +start,count @@", a missing count means one line.
+func parseHunkHeader(line string) (removed int, addedStart int, added int) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, 0
+	}
+
+	_, removed = parseHunkRange(strings.TrimPrefix(fields[1], "-"))
+	addedStart, added = parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+	return removed, addedStart, added
+}
+
+func parseHunkRange(hunkRange string) (int, int) {
+	start, count, found := strings.Cut(hunkRange, ",")
+	startLine, _ := strconv.Atoi(start)
+	if !found {
+		return startLine, 1
+	}
+
+	lines, _ := strconv.Atoi(count)
+	return startLine, lines
+}
@@ -9,7 +9,6 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
-	models "github.com/vumanhcuongit/scan/pkg/models"
 )
 
 // MockIGitScan is a mock of IGitScan interface.
@@ -36,16 +35,16 @@ func (m *MockIGitScan) EXPECT() *MockIGitScanMockRecorder {
 }
 
 // Scan mocks base method.
-func (m *MockIGitScan) Scan(ctx context.Context, ownerName, repoName string) ([]models.Finding, error) {
+func (m *MockIGitScan) Scan(ctx context.Context, target *Target) (*Result, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Scan", ctx, ownerName, repoName)
-	ret0, _ := ret[0].([]models.Finding)
+	ret := m.ctrl.Call(m, "Scan", ctx, target)
+	ret0, _ := ret[0].(*Result)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Scan indicates an expected call of Scan.
-func (mr *MockIGitScanMockRecorder) Scan(ctx, ownerName, repoName interface{}) *gomock.Call {
+func (mr *MockIGitScanMockRecorder) Scan(ctx, target interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockIGitScan)(nil).Scan), ctx, ownerName, repoName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockIGitScan)(nil).Scan), ctx, target)
 }
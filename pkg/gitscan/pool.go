@@ -0,0 +1,142 @@
+package gitscan
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+// newConcurrency falls back to one goroutine per CPU when the pool size is unset.
+func newConcurrency(concurrency int) int {
+	if concurrency <= 0 {
+		return runtime.NumCPU()
+	}
+
+	return concurrency
+}
+
+// scanPool scans files on a bounded number of goroutines. The results come in the
+// order the files were submitted, whatever order the workers finish in.
+type scanPool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	scan   func(path string, content []byte) ([]models.Finding, error)
+	files  chan pooledFile
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	results []fileResult // by submission order
+	err     error        // first failure, it stops the pool
+}
+
+// fileResult is the outcome of the scan of a file, binary files are not scanned.
+type fileResult struct {
+	path     string
+	binary   bool
+	findings []models.Finding
+}
+
+// pooledFile is a file waiting for a worker, read loads its content.
+type pooledFile struct {
+	index int
+	path  string
+	read  func() ([]byte, error)
+}
+
+func (g *GitScan) newScanPool(ctx context.Context) *scanPool {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &scanPool{
+		ctx:    ctx,
+		cancel: cancel,
+		scan:   g.scanContent,
+		files:  make(chan pooledFile),
+	}
+	for i := 0; i < g.concurrency; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+// submit hands a file to the next idle worker, it blocks while all of them are busy.
+// It fails once the pool stopped, the caller stops submitting and waits.
+func (p *scanPool) submit(path string, read func() ([]byte, error)) error {
+	p.mu.Lock()
+	index := len(p.results)
+	p.results = append(p.results, fileResult{path: path})
+	p.mu.Unlock()
+
+	select {
+	case p.files <- pooledFile{index: index, path: path, read: read}:
+		return nil
+	case <-p.ctx.Done():
+		return p.failure()
+	}
+}
+
+// wait stops the pool once the submitted files are scanned.
+func (p *scanPool) wait() ([]fileResult, error) {
+	close(p.files)
+	p.wg.Wait()
+	err := p.failure()
+	p.cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.results, nil
+}
+
+func (p *scanPool) work() {
+	defer p.wg.Done()
+	for file := range p.files {
+		if p.ctx.Err() != nil {
+			// drain the files submitted before the pool stopped
+			continue
+		}
+
+		content, err := file.read()
+		if err != nil {
+			p.fail(err)
+			continue
+		}
+		if isBinary(content) {
+			p.mu.Lock()
+			p.results[file.index].binary = true
+			p.mu.Unlock()
+			continue
+		}
+		findings, err := p.scan(file.path, content)
+		if err != nil {
+			p.fail(err)
+			continue
+		}
+
+		p.mu.Lock()
+		p.results[file.index].findings = findings
+		p.mu.Unlock()
+	}
+}
+
+func (p *scanPool) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+	p.cancel()
+}
+
+// failure is the error that stopped the pool, the cancellation of the scan if no file failed.
+func (p *scanPool) failure() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+
+	return p.ctx.Err()
+}
@@ -0,0 +1,103 @@
+package gitscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/internal/config"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestScanPoolKeepsSubmissionOrder(t *testing.T) {
+	gitScan := &GitScan{concurrency: 4}
+	pool := gitScan.newScanPool(context.Background())
+	pool.scan = func(path string, content []byte) ([]models.Finding, error) {
+		// the first files finish last
+		time.Sleep(time.Duration(len(content)) * time.Millisecond)
+		return []models.Finding{{RuleID: path}}, nil
+	}
+
+	expected := []string{}
+	for i := 0; i < 8; i++ {
+		path := fmt.Sprintf("%d.py", i)
+		expected = append(expected, path)
+		content := bytes.Repeat([]byte("a"), 8-i)
+		require.NoError(t, pool.submit(path, func() ([]byte, error) { return content, nil }))
+	}
+	results, err := pool.wait()
+	require.NoError(t, err)
+
+	paths := []string{}
+	for _, result := range results {
+		require.Equal(t, result.path, result.findings[0].RuleID)
+		paths = append(paths, result.path)
+	}
+	require.Equal(t, expected, paths)
+}
+
+func TestScanPoolStopsOnFailure(t *testing.T) {
+	gitScan := &GitScan{concurrency: 2}
+	pool := gitScan.newScanPool(context.Background())
+	pool.scan = func(path string, content []byte) ([]models.Finding, error) {
+		return nil, nil
+	}
+	readErr := errors.New("example error")
+
+	var err error
+	for i := 0; i < 100 && err == nil; i++ {
+		err = pool.submit("a.py", func() ([]byte, error) { return nil, readErr })
+	}
+	require.ErrorIs(t, err, readErr)
+	_, err = pool.wait()
+	require.ErrorIs(t, err, readErr)
+}
+
+func TestScanPoolWithCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gitScan := &GitScan{concurrency: 1, registry: DefaultRegistry()}
+	pool := gitScan.newScanPool(ctx)
+
+	err := pool.submit("a.py", func() ([]byte, error) { return nil, nil })
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = pool.wait()
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestScanDirIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("dir%d", i%5), fmt.Sprintf("config%d.py", i))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("private_key = \"secret\"\npassword = \"secret\"\n"), 0o644))
+	}
+	sequential := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{Concurrency: 1}).(*GitScan)
+	parallel := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{Concurrency: 8}).(*GitScan)
+
+	expected, _, err := sequential.scanDir(context.Background(), dir)
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+	for i := 0; i < 5; i++ {
+		findings, _, err := parallel.scanDir(context.Background(), dir)
+		require.NoError(t, err)
+		require.Equal(t, expected, findings)
+	}
+}
+
+func TestScanDirWithCancelledContext(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.py"), []byte("password = \"secret\"\n"), 0o644))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{Concurrency: 1}).(*GitScan)
+
+	_, _, err := gitScan.scanDir(ctx, dir)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,43 @@
+package gitscan
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/internal/config"
+)
+
+// SourceProvider fetches the repositories of a hosting service.
+type SourceProvider interface {
+	Fetcher
+	// Serves reports whether the repository at the URL is hosted by the provider.
+	Serves(repositoryURL *url.URL) bool
+}
+
+// newSourceProviders lists the providers in the order they are tried, plain git
+// serves every host and comes last.
+func newSourceProviders(
+	httpClient *http.Client,
+	cfg *config.ScannerConfig,
+	gitFetcher *GitFetcher,
+	limits ExtractLimits,
+) []SourceProvider {
+	return []SourceProvider{
+		NewGitHubProvider(httpClient, limits),
+		NewGitLabProvider(httpClient, splitHosts(cfg.GitLab.Hosts), limits),
+		gitFetcher,
+	}
+}
+
+// splitHosts parses a comma separated list of hosts.
+func splitHosts(hosts string) []string {
+	var result []string
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			result = append(result, host)
+		}
+	}
+
+	return result
+}
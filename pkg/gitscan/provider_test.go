@@ -0,0 +1,181 @@
+package gitscan
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/internal/config"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestFetcherFor(t *testing.T) {
+	cfg := &config.ScannerConfig{GitLab: config.GitLabConfig{Hosts: " gitlab.acme.io , ,Git.Example.com"}}
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), cfg).(*GitScan)
+
+	testCases := []struct {
+		name     string
+		target   *Target
+		expected Fetcher
+	}{
+		{name: "github", target: &Target{URL: "https://github.com/acme/app"}, expected: gitScan.providers[0]},
+		{name: "target without url", target: &Target{Owner: "acme", Repo: "app"}, expected: gitScan.providers[0]},
+		{name: "gitlab.com", target: &Target{URL: "https://gitlab.com/acme/app"}, expected: gitScan.providers[1]},
+		{name: "self-managed gitlab", target: &Target{URL: "https://gitlab.acme.io/platform/backend/app"}, expected: gitScan.providers[1]},
+		{name: "host case", target: &Target{URL: "https://git.example.com/acme/app"}, expected: gitScan.providers[1]},
+		{name: "bitbucket", target: &Target{URL: "https://bitbucket.org/acme/app"}, expected: gitScan.gitFetcher},
+		{name: "history", target: &Target{URL: "https://github.com/acme/app", History: true}, expected: gitScan.gitFetcher},
+		{
+			name:     "token",
+			target:   &Target{URL: "https://github.com/acme/app", Credential: &Credential{Kind: models.CredentialKindToken}},
+			expected: gitScan.providers[0],
+		},
+		{
+			name:     "deploy key",
+			target:   &Target{URL: "https://github.com/acme/app", Credential: &Credential{Kind: models.CredentialKindDeployKey}},
+			expected: gitScan.gitFetcher,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Same(t, tc.expected, gitScan.fetcherFor(tc.target))
+		})
+	}
+
+	cloneOnly := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{Fetcher: FetcherGit}).(*GitScan)
+	require.Same(t, cloneOnly.gitFetcher, cloneOnly.fetcherFor(&Target{URL: "https://github.com/acme/app"}))
+}
+
+func TestDownloadArchiveOutlivesClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	body, err := downloadArchive(&http.Client{Timeout: 50 * time.Millisecond}, request)
+	require.NoError(t, err)
+	defer body.Close()
+
+	// a streamed scan reads the archive for longer than the client timeout
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.Equal(t, "archive", string(content))
+}
+
+func TestDownloadArchiveWithCancelledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	body, err := downloadArchive(&http.Client{}, request)
+	require.NoError(t, err)
+	defer body.Close()
+
+	_, err = io.ReadAll(body)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestGitLabProvider(t *testing.T) {
+	archive := newTarball(t, map[string]string{
+		"payments-" + exampleCommitSHA + "-" + exampleCommitSHA + "/config.py": "private_key = \"secret\"\n",
+	})
+	projectPath := "/api/v4/projects/platform%2Fbackend%2Fpayments"
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.EscapedPath())
+		switch r.URL.EscapedPath() {
+		case projectPath:
+			_, _ = w.Write([]byte(`{"id": 42, "default_branch": "main"}`))
+		case projectPath + "/repository/commits/main", projectPath + "/repository/commits/release%2Fv1":
+			_, _ = w.Write([]byte(`{"id": "` + exampleCommitSHA + `"}`))
+		case projectPath + "/repository/archive.tar.gz":
+			require.Equal(t, exampleCommitSHA, r.URL.Query().Get("sha"))
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	cfg := &config.ScannerConfig{GitLab: config.GitLabConfig{Hosts: serverURL.Host}}
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), cfg)
+	target := &Target{URL: server.URL + "/platform/backend/payments", Owner: "platform/backend", Repo: "payments"}
+
+	result, err := gitScan.Scan(context.Background(), target)
+	require.NoError(t, err)
+	require.Equal(t, exampleCommitSHA, result.CommitSHA)
+	require.Len(t, result.Findings, 1)
+	require.Equal(t, "config.py", result.Findings[0].Location.Path)
+	require.Equal(t, []string{
+		projectPath,
+		projectPath + "/repository/commits/main",
+		projectPath + "/repository/archive.tar.gz",
+	}, requests)
+
+	target.Ref = "release/v1"
+	result, err = gitScan.Scan(context.Background(), target)
+	require.NoError(t, err)
+	require.Equal(t, exampleCommitSHA, result.CommitSHA)
+
+	target.Ref = "missing"
+	_, err = gitScan.Scan(context.Background(), target)
+	require.ErrorIs(t, err, ErrRefNotFound)
+	require.Equal(t, models.ScanErrorRefNotFound, ErrorCode(err))
+
+	target.Repo = "missing"
+	_, err = gitScan.Scan(context.Background(), target)
+	require.ErrorIs(t, err, ErrRepositoryNotFound)
+	require.Equal(t, models.ScanErrorRepoNotFound, ErrorCode(err))
+}
+
+func TestGitLabProviderWithToken(t *testing.T) {
+	archive := newTarball(t, map[string]string{"private-" + exampleCommitSHA + "/config.py": "private_key = \"secret\"\n"})
+	projectPath := "/api/v4/projects/acme%2Fprivate"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-secret" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case projectPath + "/repository/commits/main":
+			_, _ = w.Write([]byte(`{"id": "` + exampleCommitSHA + `"}`))
+		case projectPath + "/repository/archive.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{GitLab: config.GitLabConfig{Hosts: serverURL.Host}})
+	target := &Target{URL: server.URL + "/acme/private", Owner: "acme", Repo: "private", Ref: "main"}
+
+	_, err = gitScan.Scan(context.Background(), target)
+	// GitLab hides the private projects from who has no access
+	require.ErrorIs(t, err, ErrRepositoryNotFound)
+
+	target.Credential = &Credential{Kind: models.CredentialKindToken, Secret: "glpat-secret"}
+	result, err := gitScan.Scan(context.Background(), target)
+	require.NoError(t, err)
+	require.Equal(t, exampleCommitSHA, result.CommitSHA)
+	require.Len(t, result.Findings, 1)
+}
@@ -0,0 +1,309 @@
+package gitscan
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+const (
+	typeSast = "sast"
+
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+// RuleInfo describes a rule, every finding carries the ID, description and
+// severity of the rule that produced it.
+type RuleInfo interface {
+	ID() string
+	Description() string
+	Severity() string
+}
+
+// Rule inspects a single line of a file and reports the findings it matches.
+// The caller is responsible for filling in the line number of each finding.
+type Rule interface {
+	RuleInfo
+	Match(path string, line string) []models.Finding
+}
+
+// FileRule inspects the whole content of a file, it is meant for detectors
+// whose secrets span several lines. Findings carry their own line numbers.
+type FileRule interface {
+	RuleInfo
+	MatchFile(path string, content []byte) []models.Finding
+}
+
+// Registry holds the rules that GitScan runs against every scanned file. Files are
+// scanned in parallel, so rules must be safe to match from several goroutines.
+type Registry struct {
+	rules     []Rule
+	fileRules []FileRule
+	ids       map[string]struct{}
+}
+
+func NewRegistry(rules ...Rule) (*Registry, error) {
+	registry := &Registry{ids: map[string]struct{}{}}
+	for _, rule := range rules {
+		if err := registry.Register(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
+}
+
+// Register adds a line rule to the registry, rule IDs must be unique.
+func (r *Registry) Register(rule Rule) error {
+	if err := r.reserveID(rule); err != nil {
+		return err
+	}
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+// RegisterFileRule adds a file rule to the registry, rule IDs must be unique.
+func (r *Registry) RegisterFileRule(rule FileRule) error {
+	if err := r.reserveID(rule); err != nil {
+		return err
+	}
+	r.fileRules = append(r.fileRules, rule)
+	return nil
+}
+
+func (r *Registry) reserveID(rule RuleInfo) error {
+	if rule.ID() == "" {
+		return fmt.Errorf("rule has an empty id")
+	}
+	if _, ok := r.ids[rule.ID()]; ok {
+		return fmt.Errorf("rule %s is already registered", rule.ID())
+	}
+	r.ids[rule.ID()] = struct{}{}
+	return nil
+}
+
+func (r *Registry) Rules() []Rule {
+	return r.rules
+}
+
+func (r *Registry) FileRules() []FileRule {
+	return r.fileRules
+}
+
+// Match runs every line rule against the line and returns their findings in registration order.
+func (r *Registry) Match(path string, line string) []models.Finding {
+	var findings []models.Finding
+	for _, rule := range r.rules {
+		findings = append(findings, rule.Match(path, line)...)
+	}
+
+	return findings
+}
+
+// MatchFile runs every file rule against the content and returns their findings in registration order.
+func (r *Registry) MatchFile(path string, content []byte) []models.Finding {
+	var findings []models.Finding
+	for _, rule := range r.fileRules {
+		findings = append(findings, rule.MatchFile(path, content)...)
+	}
+
+	return findings
+}
+
+// PrefixRule flags lines starting with one of its prefixes.
+type PrefixRule struct {
+	id          string
+	description string
+	severity    string
+	prefixes    []string
+}
+
+func NewPrefixRule(id string, description string, severity string, prefixes ...string) *PrefixRule {
+	return &PrefixRule{
+		id:          id,
+		description: description,
+		severity:    severity,
+		prefixes:    prefixes,
+	}
+}
+
+func (r *PrefixRule) ID() string {
+	return r.id
+}
+
+func (r *PrefixRule) Description() string {
+	return r.description
+}
+
+func (r *PrefixRule) Severity() string {
+	return r.severity
+}
+
+func (r *PrefixRule) Match(path string, line string) []models.Finding {
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(line, prefix) {
+			end := len(strings.TrimRightFunc(line, unicode.IsSpace))
+			return []models.Finding{newLineFinding(r, path, line, 0, end)}
+		}
+	}
+
+	return nil
+}
+
+// RegexRule flags every match of its regular expression, the finding spans the
+// first capturing group when there is one. Keywords, when set,
+// are checked case-insensitively before the regular expression runs, and the
+// include/exclude globs restrict the files the rule applies to.
+type RegexRule struct {
+	id          string
+	description string
+	severity    string
+	regex       *regexp.Regexp
+	keywords    []string
+	include     []*glob
+	exclude     []*glob
+}
+
+type RegexRuleOptions struct {
+	Keywords []string
+	Include  []string
+	Exclude  []string
+}
+
+func NewRegexRule(
+	id string,
+	description string,
+	severity string,
+	pattern string,
+	opts *RegexRuleOptions,
+) (*RegexRule, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: invalid regex: %w", id, err)
+	}
+
+	rule := &RegexRule{
+		id:          id,
+		description: description,
+		severity:    severity,
+		regex:       regex,
+	}
+	if opts == nil {
+		return rule, nil
+	}
+
+	for _, keyword := range opts.Keywords {
+		rule.keywords = append(rule.keywords, strings.ToLower(keyword))
+	}
+	rule.include, err = compileGlobs(opts.Include)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %w", id, err)
+	}
+	rule.exclude, err = compileGlobs(opts.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %w", id, err)
+	}
+
+	return rule, nil
+}
+
+func (r *RegexRule) ID() string {
+	return r.id
+}
+
+func (r *RegexRule) Description() string {
+	return r.description
+}
+
+func (r *RegexRule) Severity() string {
+	return r.severity
+}
+
+func (r *RegexRule) Match(path string, line string) []models.Finding {
+	if !r.appliesTo(path) || !r.containsKeyword(line) {
+		return nil
+	}
+
+	var findings []models.Finding
+	for _, match := range r.regex.FindAllStringSubmatchIndex(line, -1) {
+		start, end := match[0], match[1]
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+		findings = append(findings, newLineFinding(r, path, line, start, end))
+	}
+
+	return findings
+}
+
+func (r *RegexRule) appliesTo(path string) bool {
+	if len(r.include) > 0 && !matchAnyGlob(r.include, path) {
+		return false
+	}
+	return !matchAnyGlob(r.exclude, path)
+}
+
+func (r *RegexRule) containsKeyword(line string) bool {
+	if len(r.keywords) == 0 {
+		return true
+	}
+
+	lowerLine := strings.ToLower(line)
+	for _, keyword := range r.keywords {
+		if strings.Contains(lowerLine, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newLineFinding returns a finding spanning line[start:end], the caller fills in the line number.
+func newLineFinding(rule RuleInfo, path string, line string, start int, end int) models.Finding {
+	finding := newFinding(rule, path)
+	finding.Location.Position.Begin.Column = columnAt(line, start)
+	finding.Location.Position.End.Column = columnAt(line, end)
+	return finding
+}
+
+// newContentFinding returns a finding spanning content[start:end].
+func newContentFinding(rule RuleInfo, path string, content []byte, start int, end int) models.Finding {
+	finding := newFinding(rule, path)
+	position := &finding.Location.Position
+	position.Begin.Line, position.Begin.Column = positionAt(content, start)
+	position.End.Line, position.End.Column = positionAt(content, end)
+	return finding
+}
+
+// columnAt returns the 1-based character column of the byte at offset.
+func columnAt(line string, offset int) int {
+	return utf8.RuneCountInString(line[:offset]) + 1
+}
+
+// positionAt returns the 1-based line and character column of the byte at offset.
+func positionAt(content []byte, offset int) (int, int) {
+	lineStart := bytes.LastIndexByte(content[:offset], '\n') + 1
+	return bytes.Count(content[:offset], []byte("\n")) + 1, utf8.RuneCount(content[lineStart:offset]) + 1
+}
+
+func newFinding(rule RuleInfo, path string) models.Finding {
+	return models.Finding{
+		Type:   typeSast,
+		RuleID: rule.ID(),
+		Location: models.Location{
+			Path: path,
+		},
+		Metadata: models.Metadata{
+			Description: rule.Description(),
+			Severity:    rule.Severity(),
+		},
+	}
+}
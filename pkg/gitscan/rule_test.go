@@ -0,0 +1,69 @@
+package gitscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+type fakeRule struct {
+	id string
+}
+
+func (r *fakeRule) ID() string          { return r.id }
+func (r *fakeRule) Description() string { return "fake rule" }
+func (r *fakeRule) Severity() string    { return SeverityLow }
+func (r *fakeRule) Match(path string, line string) []models.Finding {
+	if line == "" {
+		return nil
+	}
+	return []models.Finding{newFinding(r, path)}
+}
+
+func TestPrefixRule(t *testing.T) {
+	rule := NewPrefixRule("G101", "Potential hardcoded credentials", SeverityHigh, "private_key", "public_key")
+	testCases := []struct {
+		name     string
+		line     string
+		expected int
+	}{
+		{name: "private key", line: "private_key = abc", expected: 1},
+		{name: "public key", line: "public_key: abc", expected: 1},
+		{name: "indented", line: "  private_key = abc", expected: 0},
+		{name: "unrelated", line: "username = abc", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := rule.Match("config/app.yaml", tc.line)
+			require.Len(t, findings, tc.expected)
+			for _, finding := range findings {
+				require.Equal(t, "G101", finding.RuleID)
+				require.Equal(t, "config/app.yaml", finding.Location.Path)
+				require.Equal(t, SeverityHigh, finding.Metadata.Severity)
+				require.Equal(t, "Potential hardcoded credentials", finding.Metadata.Description)
+			}
+		})
+	}
+}
+
+func TestRegistryMatch(t *testing.T) {
+	registry, err := NewRegistry(&fakeRule{id: "F1"}, &fakeRule{id: "F2"})
+	require.NoError(t, err)
+	require.Len(t, registry.Rules(), 2)
+
+	findings := registry.Match("main.go", "some content")
+	require.Len(t, findings, 2)
+	require.Equal(t, "F1", findings[0].RuleID)
+	require.Equal(t, "F2", findings[1].RuleID)
+
+	require.Empty(t, registry.Match("main.go", ""))
+}
+
+func TestRegistryRejectsInvalidRules(t *testing.T) {
+	_, err := NewRegistry(&fakeRule{id: "F1"}, &fakeRule{id: "F1"})
+	require.Error(t, err)
+
+	_, err = NewRegistry(&fakeRule{})
+	require.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package gitscan
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// RulePack is the YAML format used to ship extra regex rules without a new release, e.g.
+//
+//	rules:
+//	  - id: ACME001
+//	    description: ACME API token
+//	    severity: HIGH
+//	    regex: 'acme_[a-z0-9]{32}'
+//	    keywords: [acme_]
+//	    paths:
+//	      include: ["**/*.go"]
+//	      exclude: ["**/testdata/**"]
+type RulePack struct {
+	Rules []RuleSpec `yaml:"rules"`
+}
+
+type RuleSpec struct {
+	ID          string        `yaml:"id"`
+	Description string        `yaml:"description"`
+	Severity    string        `yaml:"severity"`
+	Regex       string        `yaml:"regex"`
+	Keywords    []string      `yaml:"keywords"`
+	Paths       RulePathsSpec `yaml:"paths"`
+}
+
+type RulePathsSpec struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// LoadRegistry returns the default registry extended with the entropy rule
+// when it is enabled and with the rules of the configured rule pack.
+func LoadRegistry(cfg *config.ScannerConfig) (*Registry, error) {
+	registry := DefaultRegistry()
+	if cfg.Entropy.Enabled {
+		err := registry.Register(NewEntropyRule(&EntropyOptions{
+			Base64Threshold: cfg.Entropy.Base64Threshold,
+			HexThreshold:    cfg.Entropy.HexThreshold,
+			MinLength:       cfg.Entropy.MinLength,
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.RulesFile == "" {
+		return registry, nil
+	}
+	rules, err := LoadRulePack(cfg.RulesFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		if err = registry.Register(rule); err != nil {
+			return nil, fmt.Errorf("rule pack %s: %w", cfg.RulesFile, err)
+		}
+	}
+
+	return registry, nil
+}
+
+// LoadRulePack reads and compiles the rule pack at filePath.
+func LoadRulePack(filePath string) ([]Rule, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ParseRulePack(data)
+	if err != nil {
+		return nil, fmt.Errorf("rule pack %s: %w", filePath, err)
+	}
+
+	return rules, nil
+}
+
+// ParseRulePack compiles a YAML rule pack, it fails on the first invalid rule.
+func ParseRulePack(data []byte) ([]Rule, error) {
+	pack := &RulePack{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(pack); err != nil {
+		return nil, fmt.Errorf("failed to parse rule pack: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(pack.Rules))
+	for i, spec := range pack.Rules {
+		rule, err := spec.compile()
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (s *RuleSpec) compile() (Rule, error) {
+	if s.ID == "" {
+		return nil, fmt.Errorf("missing id")
+	}
+	if s.Regex == "" {
+		return nil, fmt.Errorf("rule %s: missing regex", s.ID)
+	}
+
+	severity := strings.ToUpper(s.Severity)
+	if !isValidSeverity(severity) {
+		return nil, fmt.Errorf("rule %s: invalid severity %q", s.ID, s.Severity)
+	}
+
+	return NewRegexRule(s.ID, s.Description, severity, s.Regex, &RegexRuleOptions{
+		Keywords: s.Keywords,
+		Include:  s.Paths.Include,
+		Exclude:  s.Paths.Exclude,
+	})
+}
+
+func isValidSeverity(severity string) bool {
+	switch severity {
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		return true
+	}
+	return false
+}
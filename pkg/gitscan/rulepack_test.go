@@ -0,0 +1,105 @@
+package gitscan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/internal/config"
+)
+
+const exampleRulePack = `
+rules:
+  - id: ACME001
+    description: ACME API token
+    severity: high
+    regex: 'acme_[a-z0-9]{8}'
+    keywords: [ACME_]
+    paths:
+      include: ["**/*.go", "*.env"]
+      exclude: ["**/testdata/**"]
+`
+
+func TestParseRulePack(t *testing.T) {
+	rules, err := ParseRulePack([]byte(exampleRulePack))
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	rule := rules[0]
+	require.Equal(t, "ACME001", rule.ID())
+	require.Equal(t, SeverityHigh, rule.Severity())
+
+	testCases := []struct {
+		name     string
+		path     string
+		line     string
+		expected int
+	}{
+		{name: "match in go file", path: "cmd/main.go", line: `token := "acme_abcd1234"`, expected: 1},
+		{name: "multiple matches", path: "main.go", line: `acme_abcd1234, acme_efgh5678`, expected: 2},
+		{name: "match in env file", path: "deploy/.env", line: `TOKEN=acme_abcd1234`, expected: 1},
+		{name: "not included", path: "README.md", line: `acme_abcd1234`, expected: 0},
+		{name: "excluded", path: "pkg/testdata/main.go", line: `acme_abcd1234`, expected: 0},
+		{name: "keyword without match", path: "main.go", line: `acme_`, expected: 0},
+		{name: "no keyword", path: "main.go", line: `token := "abcd1234"`, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := rule.Match(tc.path, tc.line)
+			require.Len(t, findings, tc.expected)
+			for _, finding := range findings {
+				require.Equal(t, "ACME001", finding.RuleID)
+				require.Equal(t, "ACME API token", finding.Metadata.Description)
+			}
+		})
+	}
+}
+
+func TestParseRulePackWithInvalidRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		pack string
+	}{
+		{name: "missing id", pack: "rules:\n  - regex: abc\n    severity: HIGH\n"},
+		{name: "missing regex", pack: "rules:\n  - id: R1\n    severity: HIGH\n"},
+		{name: "invalid regex", pack: "rules:\n  - id: R1\n    regex: 'a(b'\n    severity: HIGH\n"},
+		{name: "invalid severity", pack: "rules:\n  - id: R1\n    regex: abc\n    severity: URGENT\n"},
+		{name: "invalid glob", pack: "rules:\n  - id: R1\n    regex: abc\n    severity: HIGH\n    paths:\n      include: ['[a-']\n"},
+		{name: "unknown field", pack: "rules:\n  - id: R1\n    regexp: abc\n    severity: HIGH\n"},
+		{name: "malformed yaml", pack: "rules: ["},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRulePack([]byte(tc.pack))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	cfg := &config.ScannerConfig{}
+	registry, err := LoadRegistry(cfg)
+	require.NoError(t, err)
+	defaultRules := len(registry.Rules())
+	require.NotZero(t, defaultRules)
+
+	cfg.Entropy.Enabled = true
+	registry, err = LoadRegistry(cfg)
+	require.NoError(t, err)
+	require.Len(t, registry.Rules(), defaultRules+1)
+
+	cfg.RulesFile = filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(cfg.RulesFile, []byte(exampleRulePack), 0o600))
+	registry, err = LoadRegistry(cfg)
+	require.NoError(t, err)
+	require.Len(t, registry.Rules(), defaultRules+2)
+
+	require.NoError(t, os.WriteFile(cfg.RulesFile, []byte("rules:\n  - id: G101\n    regex: abc\n    severity: LOW\n"), 0o600))
+	_, err = LoadRegistry(cfg)
+	require.Error(t, err)
+
+	cfg.RulesFile = filepath.Join(t.TempDir(), "missing.yaml")
+	_, err = LoadRegistry(cfg)
+	require.Error(t, err)
+}
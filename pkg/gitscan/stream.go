@@ -0,0 +1,169 @@
+package gitscan
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+	"go.uber.org/zap"
+)
+
+// scanStream scans the archive of a target as it downloads, nothing is written on the disk.
+func (g *GitScan) scanStream(ctx context.Context, provider ArchiveProvider, target *Target) (*Result, error) {
+	log := zap.S()
+	archive, err := provider.OpenArchive(ctx, target)
+	if err != nil {
+		log.Warnf("failed to open archive, err: %+v", err)
+		return nil, err
+	}
+	defer archive.Close()
+
+	findings, stats, err := g.scanArchive(ctx, archive)
+	if err != nil {
+		log.Warnf("failed to scan archive, err: %+v", err)
+		return nil, err
+	}
+
+	return &Result{CommitSHA: archive.CommitSHA, Findings: findings, Stats: stats}, nil
+}
+
+// scanArchive scans the regular files of a gzipped tarball as they stream, under the
+// same limits as an extraction. Paths are relative to the top level directory and
+// the findings are in the order a scan of the extracted tree would report them.
+// Hardlinks are skipped, the file they point to is scanned already. The files are
+// skipped as in an extracted tree, except that .scanignore only applies to the
+// entries after it, those before are scanned and their findings dropped.
+func (g *GitScan) scanArchive(ctx context.Context, reader io.Reader) ([]models.Finding, *models.ScanStats, error) {
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	ignores := newIgnoreList()
+	tally := newScanTally()
+	pool := g.newScanPool(ctx)
+	err = g.submitArchive(ctx, pool, gz, ignores, tally)
+	results, poolErr := pool.wait()
+	if err != nil {
+		return nil, nil, err
+	}
+	if poolErr != nil {
+		return nil, nil, poolErr
+	}
+
+	findings := tally.merge(results, ignores)
+	parseCommittedBaseline(tally.baseline).Apply(findings)
+	sortFindingsByPath(findings)
+	return findings, &tally.stats, nil
+}
+
+// submitArchive reads the entries of a tarball in turn and hands their content to
+// the pool, the archive is a stream so only the scan runs in parallel.
+func (g *GitScan) submitArchive(
+	ctx context.Context,
+	pool *scanPool,
+	reader io.Reader,
+	ignores *ignoreList,
+	tally *scanTally,
+) error {
+	remainingBytes := g.limits.MaxBytes
+	files := 0
+	tr := tar.NewReader(reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		files++
+		if files > g.limits.MaxFiles {
+			return ErrArchiveTooManyFiles
+		}
+		name, err := localPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		_, relativePath, found := strings.Cut(filepath.ToSlash(name), "/")
+		if !found {
+			// outside of the top level directory
+			continue
+		}
+
+		// skipped files are not read but count against the limits as if extracted
+		skipped := true
+		if ignoredPath, ignored := ignores.match(relativePath); ignored {
+			tally.ignore(ignoredPath)
+		} else if hdr.Size > g.maxFileSize {
+			tally.stats.OversizedFiles++
+		} else {
+			skipped = false
+		}
+		if skipped {
+			if hdr.Size > remainingBytes {
+				return ErrArchiveTooLarge
+			}
+			remainingBytes -= hdr.Size
+			continue
+		}
+
+		content, err := io.ReadAll(io.LimitReader(tr, remainingBytes+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(content)) > remainingBytes {
+			return ErrArchiveTooLarge
+		}
+		remainingBytes -= int64(len(content))
+		if relativePath == scanIgnoreFile {
+			ignores.add(content)
+		}
+		if relativePath == models.BaselineFile {
+			tally.baseline = content
+			continue
+		}
+
+		err = pool.submit(relativePath, func() ([]byte, error) {
+			return content, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sortFindingsByPath orders the findings like a walk of the tree does, directory by
+// directory in lexical order, the findings of a file keep their order.
+func sortFindingsByPath(findings []models.Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return lessPath(findings[i].Location.Path, findings[j].Location.Path)
+	})
+}
+
+// lessPath compares slash separated paths segment by segment, so that "a/b" comes
+// before "a.txt" as filepath.Walk visits them.
+func lessPath(a string, b string) bool {
+	aSegments, bSegments := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(aSegments) && i < len(bSegments); i++ {
+		if aSegments[i] != bSegments[i] {
+			return aSegments[i] < bSegments[i]
+		}
+	}
+
+	return len(aSegments) < len(bSegments)
+}
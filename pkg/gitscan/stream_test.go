@@ -0,0 +1,131 @@
+package gitscan
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/internal/config"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestScanStream(t *testing.T) {
+	archive := newTarball(t, map[string]string{
+		"acme-app-2d4a8f3/z.py":     "private_key = \"secret\"\n",
+		"acme-app-2d4a8f3/a.txt":    "private_key = \"secret\"\n",
+		"acme-app-2d4a8f3/a/b.py":   "# settings\nprivate_key = \"secret\"\n",
+		"acme-app-2d4a8f3/README":   "# app\n",
+		"acme-app-2d4a8f3/a/c/d.py": "private_key = \"secret\"\n",
+	})
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/app/commits/HEAD":
+			_, _ = w.Write([]byte(exampleCommitSHA))
+		case "/repos/acme/app/tarball/" + exampleCommitSHA:
+			http.Redirect(w, r, server.URL+"/archive.tar.gz", http.StatusFound)
+		case "/archive.tar.gz":
+			_, _ = w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	target := &Target{Owner: "acme", Repo: "app"}
+
+	expected, err := newTestGitScan(t, server.URL).Scan(context.Background(), target)
+	require.NoError(t, err)
+
+	gitScan := newTestGitScan(t, server.URL)
+	gitScan.stream = true
+	// the source codes directory is not even created
+	gitScan.sourceCodesDir = filepath.Join(t.TempDir(), "missing")
+	result, err := gitScan.Scan(context.Background(), target)
+	require.NoError(t, err)
+	require.Equal(t, exampleCommitSHA, result.CommitSHA)
+	require.Equal(t, expected.Findings, result.Findings)
+	paths := []string{}
+	for _, finding := range result.Findings {
+		paths = append(paths, finding.Location.Path)
+	}
+	require.Equal(t, []string{"a/b.py", "a/c/d.py", "a.txt", "z.py"}, paths)
+	require.Equal(t, 2, result.Findings[0].Location.Position.Begin.Line)
+	require.NoDirExists(t, gitScan.sourceCodesDir)
+}
+
+func TestScanArchive(t *testing.T) {
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{}).(*GitScan)
+	gitScan.limits = ExtractLimits{MaxBytes: 64, MaxFiles: 3}
+	gzipped := func(entries ...tarEntry) *bytes.Reader {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write(newTar(t, entries...))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	findings, _, err := gitScan.scanArchive(context.Background(), gzipped(
+		regularEntry("pax_global_header", "private_key = \"secret\"\n"),
+		regularEntry("app/config.py", "private_key = \"secret\"\n"),
+		linkEntry(tar.TypeLink, "app/settings.py", "app/config.py"),
+		linkEntry(tar.TypeSymlink, "app/passwd", "/etc/passwd"),
+	))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, "config.py", findings[0].Location.Path)
+
+	_, _, err = gitScan.scanArchive(context.Background(), gzipped(regularEntry("app/../../config.py", "")))
+	require.ErrorIs(t, err, ErrUnsafeArchivePath)
+	_, _, err = gitScan.scanArchive(context.Background(), gzipped(
+		regularEntry("app/a", ""), regularEntry("app/b", ""), regularEntry("app/c", ""), regularEntry("app/d", ""),
+	))
+	require.ErrorIs(t, err, ErrArchiveTooManyFiles)
+	_, _, err = gitScan.scanArchive(context.Background(), gzipped(
+		regularEntry("app/a", string(bytes.Repeat([]byte("a"), 40))),
+		regularEntry("app/b", string(bytes.Repeat([]byte("b"), 40))),
+	))
+	require.ErrorIs(t, err, ErrArchiveTooLarge)
+	_, _, err = gitScan.scanArchive(context.Background(), bytes.NewReader([]byte("not gzip")))
+	require.Error(t, err)
+}
+
+func TestScanArchiveSkipsFiles(t *testing.T) {
+	gitScan := NewGitScan(t.TempDir(), DefaultRegistry(), &config.ScannerConfig{MaxFileSize: 64}).(*GitScan)
+	secret := "private_key = \"secret\"\n"
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(newTar(t,
+		// scanned before .scanignore tells to skip them
+		regularEntry("app/docs/a.md", secret),
+		regularEntry("app/docs/b.md", secret),
+		regularEntry("app/"+scanIgnoreFile, "docs/\n"),
+		regularEntry("app/config.py", secret),
+		regularEntry("app/node_modules/lib/config.js", secret),
+		regularEntry("app/logo.png", "\x89PNG\x00"+secret),
+		regularEntry("app/large.py", secret+strings.Repeat("#", 64)),
+	))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	findings, stats, err := gitScan.scanArchive(context.Background(), &buf)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, "config.py", findings[0].Location.Path)
+	require.Equal(t, &models.ScanStats{ScannedFiles: 2, IgnoredPaths: 2, BinaryFiles: 1, OversizedFiles: 1}, stats)
+}
+
+func TestLessPath(t *testing.T) {
+	require.True(t, lessPath("a/b", "a.txt"))
+	require.False(t, lessPath("a.txt", "a/b"))
+	require.True(t, lessPath("a", "a/b"))
+	require.True(t, lessPath("a/b/c", "a/c"))
+	require.False(t, lessPath("a", "a"))
+}
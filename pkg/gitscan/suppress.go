@@ -0,0 +1,126 @@
+package gitscan
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+// suppressionMarker starts an inline suppression in a comment of any language, e.g.
+//
+//	password = "hunter2" // scan:ignore G101 reason="test fixture"
+//
+// It may list the IDs of the rules it covers, separated by spaces or commas, and
+// give the reason after reason=, quoted if it has spaces.
+const suppressionMarker = "scan:ignore"
+
+// suppression is a scan:ignore comment, without rule IDs it covers every rule.
+type suppression struct {
+	ruleIDs []string
+	reason  string
+}
+
+// parseSuppression reads the scan:ignore comment of a line, if any. Parsing stops at
+// the first word that is neither a rule ID nor the reason, such as the */ closing a comment.
+func parseSuppression(line string) *suppression {
+	start := strings.Index(line, suppressionMarker)
+	if start < 0 {
+		return nil
+	}
+	rest := line[start+len(suppressionMarker):]
+	if rest != "" && !unicode.IsSpace(rune(rest[0])) {
+		// scan:ignored, scan:ignore-me and the like are not markers
+		return nil
+	}
+
+	s := &suppression{}
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			return s
+		}
+
+		if strings.HasPrefix(rest, "reason=") {
+			s.reason, rest = parseSuppressionReason(strings.TrimPrefix(rest, "reason="))
+			continue
+		}
+
+		word := rest
+		if end := strings.IndexFunc(rest, unicode.IsSpace); end >= 0 {
+			word = rest[:end]
+		}
+		ruleIDs := strings.FieldsFunc(word, func(r rune) bool { return r == ',' })
+		for _, ruleID := range ruleIDs {
+			if !isSuppressibleRuleID(ruleID) {
+				return s
+			}
+		}
+		s.ruleIDs = append(s.ruleIDs, ruleIDs...)
+		rest = rest[len(word):]
+	}
+}
+
+// parseSuppressionReason reads a quoted reason, or a single word, and returns what follows it.
+func parseSuppressionReason(value string) (string, string) {
+	if quoted, err := strconv.QuotedPrefix(value); err == nil {
+		reason, _ := strconv.Unquote(quoted)
+		return reason, value[len(quoted):]
+	}
+
+	end := strings.IndexFunc(value, unicode.IsSpace)
+	if end < 0 {
+		return value, ""
+	}
+
+	return value[:end], value[end:]
+}
+
+func isSuppressibleRuleID(ruleID string) bool {
+	for _, r := range ruleID {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.' {
+			return false
+		}
+	}
+
+	return ruleID != ""
+}
+
+func (s *suppression) covers(ruleID string) bool {
+	if len(s.ruleIDs) == 0 {
+		return true
+	}
+	for _, id := range s.ruleIDs {
+		if id == ruleID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applySuppressions marks the findings covered by a scan:ignore comment on one of
+// their lines, suppressions are keyed by line number. The findings are kept so
+// that suppressions can be audited.
+func applySuppressions(findings []models.Finding, suppressions map[int]*suppression) {
+	if len(suppressions) == 0 {
+		return
+	}
+
+	for i := range findings {
+		position := &findings[i].Location.Position
+		endLine := position.End.Line
+		if endLine < position.Begin.Line {
+			endLine = position.Begin.Line
+		}
+		for line := position.Begin.Line; line <= endLine; line++ {
+			s, found := suppressions[line]
+			if found && s.covers(findings[i].RuleID) {
+				findings[i].Suppressed = true
+				findings[i].Justification = s.reason
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package gitscan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func TestParseSuppression(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		expected *suppression
+	}{
+		{name: "no marker", line: `password = "hunter2"`},
+		{name: "every rule", line: `password = "hunter2" # scan:ignore`, expected: &suppression{}},
+		{
+			name:     "rule and quoted reason",
+			line:     `password = "hunter2" // scan:ignore G101 reason="test fixture"`,
+			expected: &suppression{ruleIDs: []string{"G101"}, reason: "test fixture"},
+		},
+		{
+			name:     "several rules",
+			line:     `key: abc # scan:ignore G101,G102 custom-rule reason=rotated`,
+			expected: &suppression{ruleIDs: []string{"G101", "G102", "custom-rule"}, reason: "rotated"},
+		},
+		{
+			name:     "escaped quote",
+			line:     `token = "x" // scan:ignore reason="the \"demo\" token"`,
+			expected: &suppression{reason: `the "demo" token`},
+		},
+		{
+			name:     "block comment",
+			line:     `const key = "x"; /* scan:ignore G104 */`,
+			expected: &suppression{ruleIDs: []string{"G104"}},
+		},
+		{
+			name:     "html comment",
+			line:     `<meta content="x"> <!-- scan:ignore reason="public key" -->`,
+			expected: &suppression{reason: "public key"},
+		},
+		{name: "not a marker", line: `// scan:ignored G101`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, parseSuppression(tc.line))
+		})
+	}
+}
+
+func TestScanContentWithSuppressions(t *testing.T) {
+	gitScan := &GitScan{registry: DefaultRegistry()}
+	content := "private_key = \"secret\" # scan:ignore G101 reason=\"test fixture\"\n" +
+		"private_key = \"secret\" # scan:ignore G102\n" +
+		"private_key = \"secret\"\n"
+
+	findings, err := gitScan.scanContent("config_test.py", []byte(content))
+	require.NoError(t, err)
+	findings = findingsOfRule(findings, "G101")
+	require.Len(t, findings, 3)
+	require.True(t, findings[0].Suppressed)
+	require.Equal(t, "test fixture", findings[0].Justification)
+	require.False(t, findings[1].Suppressed)
+	require.False(t, findings[2].Suppressed)
+}
+
+func TestApplySuppressionsOverMultipleLines(t *testing.T) {
+	findings := []models.Finding{
+		{RuleID: "G108", Location: models.Location{Position: models.Position{Begin: models.Begin{Line: 2}, End: models.End{Line: 6}}}},
+		{RuleID: "G108", Location: models.Location{Position: models.Position{Begin: models.Begin{Line: 7}, End: models.End{Line: 9}}}},
+	}
+
+	applySuppressions(findings, map[int]*suppression{4: {reason: "revoked"}})
+	require.True(t, findings[0].Suppressed)
+	require.Equal(t, "revoked", findings[0].Justification)
+	require.False(t, findings[1].Suppressed)
+}
@@ -0,0 +1,86 @@
+package gitscan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// workspacePrefix starts the name of the directory of every scan, scan-<id>-<random>.
+const workspacePrefix = "scan-"
+
+// newWorkspace creates the directory a scan fetches its source code to, no other scan uses it.
+func newWorkspace(sourceCodesDir string, scanID int64) (string, error) {
+	if err := os.MkdirAll(sourceCodesDir, 0o755); err != nil {
+		return "", err
+	}
+
+	return os.MkdirTemp(sourceCodesDir, fmt.Sprintf("%s%d-", workspacePrefix, scanID))
+}
+
+// RemoveOrphanedWorkspaces removes the workspaces a worker left behind when it
+// crashed. Only the workspaces older than maxAge, the longest a scan may last, are
+// removed so that workers may share the directory.
+func RemoveOrphanedWorkspaces(sourceCodesDir string, maxAge time.Duration) error {
+	log := zap.S()
+	entries, err := os.ReadDir(sourceCodesDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), workspacePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if os.IsNotExist(err) {
+			// the scan owning it just finished
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		log.Infof("removing orphaned workspace %s", entry.Name())
+		if err = os.RemoveAll(filepath.Join(sourceCodesDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveRoot is the top level directory of an archive extracted to an empty
+// directory, GitHub and GitLab put the source tree in it.
+func archiveRoot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	root := ""
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if root != "" {
+			return "", errors.New("archive has several top level directories")
+		}
+		root = entry.Name()
+	}
+	if root == "" {
+		return "", errors.New("empty archive")
+	}
+
+	return filepath.Join(dir, root), nil
+}
@@ -0,0 +1,129 @@
+package gitscan
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanInIsolatedWorkspaces(t *testing.T) {
+	shas := map[string]string{
+		"main":    exampleCommitSHA,
+		"release": strings.Repeat("1", 40),
+	}
+	archives := map[string][]byte{
+		exampleCommitSHA: newTarball(t, map[string]string{"acme-app-2d4a8f3/main.py": "private_key = \"secret\"\n"}),
+		shas["release"]:  newTarball(t, map[string]string{"acme-app-1111111/release.py": "private_key = \"secret\"\n"}),
+	}
+	// both scans download their archive before any of them extracts it
+	var downloads sync.WaitGroup
+	downloads.Add(len(archives))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasPrefix(path, "/repos/acme/app/commits/"):
+			_, _ = w.Write([]byte(shas[strings.TrimPrefix(path, "/repos/acme/app/commits/")]))
+		case strings.HasPrefix(path, "/repos/acme/app/tarball/"):
+			http.Redirect(w, r, server.URL+"/archives/"+strings.TrimPrefix(path, "/repos/acme/app/tarball/"), http.StatusFound)
+		case strings.HasPrefix(path, "/archives/"):
+			downloads.Done()
+			downloads.Wait()
+			_, _ = w.Write(archives[strings.TrimPrefix(path, "/archives/")])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	gitScan := newTestGitScan(t, server.URL)
+
+	paths := map[string][]string{}
+	var mu sync.Mutex
+	var scans sync.WaitGroup
+	for i, ref := range []string{"main", "release"} {
+		scans.Add(1)
+		go func(scanID int64, ref string) {
+			defer scans.Done()
+			result, err := gitScan.Scan(context.Background(), &Target{ScanID: scanID, Owner: "acme", Repo: "app", Ref: ref})
+			require.NoError(t, err)
+			mu.Lock()
+			defer mu.Unlock()
+			for _, finding := range result.Findings {
+				paths[ref] = append(paths[ref], finding.Location.Path)
+			}
+		}(int64(i+1), ref)
+	}
+	scans.Wait()
+
+	require.Equal(t, map[string][]string{"main": {"main.py"}, "release": {"release.py"}}, paths)
+	entries, err := os.ReadDir(gitScan.sourceCodesDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestScanRemovesWorkspaceOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/app/commits/HEAD":
+			_, _ = w.Write([]byte(exampleCommitSHA))
+		case "/repos/acme/app/tarball/" + exampleCommitSHA:
+			_, _ = w.Write([]byte("not an archive"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	gitScan := newTestGitScan(t, server.URL)
+
+	_, err := gitScan.Scan(context.Background(), &Target{ScanID: 1, Owner: "acme", Repo: "app"})
+	require.Error(t, err)
+	entries, err := os.ReadDir(gitScan.sourceCodesDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestRemoveOrphanedWorkspaces(t *testing.T) {
+	sourceCodesDir := t.TempDir()
+	workspace, err := newWorkspace(sourceCodesDir, 42)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(filepath.Base(workspace), "scan-42-"))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "config.py"), []byte("private_key = \"secret\"\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(sourceCodesDir, "other"), 0o755))
+	oldTime := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(workspace, oldTime, oldTime))
+	require.NoError(t, os.Chtimes(filepath.Join(sourceCodesDir, "other"), oldTime, oldTime))
+	// the scan of another worker sharing the directory
+	liveWorkspace, err := newWorkspace(sourceCodesDir, 43)
+	require.NoError(t, err)
+
+	require.NoError(t, RemoveOrphanedWorkspaces(sourceCodesDir, time.Hour))
+	require.NoDirExists(t, workspace)
+	require.DirExists(t, liveWorkspace)
+	require.DirExists(t, filepath.Join(sourceCodesDir, "other"))
+
+	require.NoError(t, RemoveOrphanedWorkspaces(filepath.Join(sourceCodesDir, "missing"), time.Hour))
+}
+
+func TestArchiveRoot(t *testing.T) {
+	dir := t.TempDir()
+	_, err := archiveRoot(dir)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pax_global_header"), nil, 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "acme-app-2d4a8f3"), 0o755))
+	root, err := archiveRoot(dir)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "acme-app-2d4a8f3"), root)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "acme-app-1111111"), 0o755))
+	_, err = archiveRoot(dir)
+	require.Error(t, err)
+}
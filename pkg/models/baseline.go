@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// BaselineFile is the baseline a repository can commit at its root, in the format of Baseline.
+const BaselineFile = ".scan-baseline.json"
+
+// Baseline lists the fingerprints of the findings a repository accepts, so that a
+// repository with many historic findings only fails on the ones introduced since.
+type Baseline struct {
+	Fingerprints []string `json:"fingerprints"`
+}
+
+// RepositoryBaseline is the baseline of a repository uploaded through the API, it
+// adds to the baseline committed in the repository.
+type RepositoryBaseline struct {
+	ID           int64          `json:"id"`
+	RepositoryID int64          `json:"repository_id"`
+	Fingerprints datatypes.JSON `json:"fingerprints"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// ParseBaseline decodes a baseline and checks that it only lists fingerprints.
+func ParseBaseline(content []byte) (*Baseline, error) {
+	baseline := &Baseline{}
+	if err := json.Unmarshal(content, baseline); err != nil {
+		return nil, err
+	}
+	if err := baseline.Validate(); err != nil {
+		return nil, err
+	}
+
+	return baseline, nil
+}
+
+func (b *Baseline) Validate() error {
+	for _, fingerprint := range b.Fingerprints {
+		if _, err := hex.DecodeString(fingerprint); err != nil || len(fingerprint) != 64 {
+			return fmt.Errorf("invalid fingerprint: %q", fingerprint)
+		}
+	}
+
+	return nil
+}
+
+// Apply marks the findings the baseline accepts as baselined.
+func (b *Baseline) Apply(findings []Finding) {
+	if b == nil || len(b.Fingerprints) == 0 {
+		return
+	}
+
+	accepted := map[string]bool{}
+	for _, fingerprint := range b.Fingerprints {
+		accepted[fingerprint] = true
+	}
+	for i := range findings {
+		if findings[i].Fingerprint != "" && accepted[findings[i].Fingerprint] {
+			findings[i].Baselined = true
+		}
+	}
+}
+
+// Baseline decodes the fingerprints of the uploaded baseline.
+func (b *RepositoryBaseline) Baseline() (*Baseline, error) {
+	baseline := &Baseline{}
+	if len(b.Fingerprints) == 0 {
+		return baseline, nil
+	}
+	if err := json.Unmarshal(b.Fingerprints, &baseline.Fingerprints); err != nil {
+		return nil, errors.New("invalid baseline fingerprints")
+	}
+
+	return baseline, nil
+}
+
+// CountFailingFindings counts the findings a check should fail on, those that are
+// neither suppressed in the source nor accepted by the baseline.
+func CountFailingFindings(findings []Finding) int {
+	count := 0
+	for _, finding := range findings {
+		if !finding.Suppressed && !finding.Baselined {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBaseline(t *testing.T) {
+	fingerprint := strings.Repeat("ab", 32)
+	baseline, err := ParseBaseline([]byte(`{"fingerprints": ["` + fingerprint + `"]}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{fingerprint}, baseline.Fingerprints)
+
+	_, err = ParseBaseline([]byte(`{"fingerprints": ["not-a-fingerprint"]}`))
+	require.Error(t, err)
+	_, err = ParseBaseline([]byte(`["` + fingerprint + `"]`))
+	require.Error(t, err)
+}
+
+func TestBaselineApply(t *testing.T) {
+	findings := []Finding{{Fingerprint: "accepted"}, {Fingerprint: "other"}, {}, {Fingerprint: "suppressed", Suppressed: true}}
+
+	(&Baseline{Fingerprints: []string{"accepted"}}).Apply(findings)
+	require.True(t, findings[0].Baselined)
+	require.False(t, findings[1].Baselined)
+	require.False(t, findings[2].Baselined)
+	require.Equal(t, 2, CountFailingFindings(findings))
+
+	var noBaseline *Baseline
+	noBaseline.Apply(findings)
+}
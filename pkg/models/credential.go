@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	CredentialKindToken     = "token"      // personal or project access token, sent over https
+	CredentialKindDeployKey = "deploy_key" // SSH private key, the repository is cloned over ssh
+)
+
+// RepositoryCredential authenticates the fetch of a private repository. The secret
+// is stored encrypted and is never returned by the API.
+type RepositoryCredential struct {
+	ID              int64     `json:"id"`
+	RepositoryID    int64     `json:"repository_id"`
+	Kind            string    `json:"kind"`
+	Username        string    `json:"username"`
+	EncryptedSecret string    `json:"-"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// SealedCredential is a repository credential on its way to the workers, the secret stays encrypted.
+type SealedCredential struct {
+	Kind            string `json:"kind"`
+	Username        string `json:"username,omitempty"`
+	EncryptedSecret string `json:"encrypted_secret"`
+}
+
+func IsValidCredentialKind(kind string) bool {
+	return kind == CredentialKindToken || kind == CredentialKindDeployKey
+}
+
+func (c *RepositoryCredential) Sealed() *SealedCredential {
+	return &SealedCredential{
+		Kind:            c.Kind,
+		Username:        c.Username,
+		EncryptedSecret: c.EncryptedSecret,
+	}
+}
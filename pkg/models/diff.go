@@ -0,0 +1,88 @@
+package models
+
+import "fmt"
+
+// ScanDiff classifies the findings of a scan against those of a base scan of the
+// same repository. Findings are matched by fingerprint, so a secret whose line moved
+// is unchanged rather than fixed and new again.
+type ScanDiff struct {
+	ScanID     int64     `json:"scan_id"`
+	BaseScanID *int64    `json:"base_scan_id"` // nil when the repository had no earlier successful scan
+	New        []Finding `json:"new"`          // reported by the scan only and not baselined
+	Fixed      []Finding `json:"fixed"`        // reported by the base scan only
+	Unchanged  []Finding `json:"unchanged"`    // reported by both or baselined, as the scan reports them
+}
+
+// NewScanDiff compares the findings of a scan with those of its base, a nil base
+// makes every finding new. Suppressed findings are left out of both sides and
+// baselined findings are never new, the repository accepted them already.
+func NewScanDiff(scan *Scan, base *Scan) (*ScanDiff, error) {
+	findings, err := scan.ParseFindings()
+	if err != nil {
+		return nil, err
+	}
+	diff := &ScanDiff{ScanID: scan.ID, New: []Finding{}, Fixed: []Finding{}, Unchanged: []Finding{}}
+	baseFindings := []Finding{}
+	if base != nil {
+		diff.BaseScanID = &base.ID
+		baseFindings, err = base.ParseFindings()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	baseKeys := findingKeys(baseFindings)
+	keys := findingKeys(findings)
+	for _, finding := range uniqueFindings(findings) {
+		if baseKeys[finding.diffKey()] || finding.Baselined {
+			diff.Unchanged = append(diff.Unchanged, finding)
+		} else {
+			diff.New = append(diff.New, finding)
+		}
+	}
+	for _, finding := range uniqueFindings(baseFindings) {
+		if !keys[finding.diffKey()] {
+			diff.Fixed = append(diff.Fixed, finding)
+		}
+	}
+
+	return diff, nil
+}
+
+// diffKey identifies a finding across scans. Findings stored before fingerprints
+// existed fall back to their rule, path and line.
+func (f *Finding) diffKey() string {
+	if f.Fingerprint != "" {
+		return f.Fingerprint
+	}
+
+	return fmt.Sprintf("%s\x00%s\x00%d", f.RuleID, f.Location.Path, f.Location.Position.Begin.Line)
+}
+
+// uniqueFindings drops the suppressed findings and the repeats of a finding, such as
+// a secret a history scan finds in several commits.
+func uniqueFindings(findings []Finding) []Finding {
+	unique := []Finding{}
+	seen := map[string]bool{}
+	for _, finding := range findings {
+		key := finding.diffKey()
+		if finding.Suppressed || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, finding)
+	}
+
+	return unique
+}
+
+func findingKeys(findings []Finding) map[string]bool {
+	keys := map[string]bool{}
+	for _, finding := range findings {
+		if !finding.Suppressed {
+			keys[finding.diffKey()] = true
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScanDiff(t *testing.T) {
+	newScan := func(id int64, findings []Finding) *Scan {
+		rawFindings, err := json.Marshal(findings)
+		require.NoError(t, err)
+		return &Scan{ID: id, Findings: rawFindings}
+	}
+	finding := func(fingerprint string, line int) Finding {
+		return Finding{
+			RuleID:      "G101",
+			Location:    Location{Path: "config.py", Position: Position{Begin: Begin{Line: line}}},
+			Fingerprint: fingerprint,
+		}
+	}
+	suppressed := finding("suppressed", 9)
+	suppressed.Suppressed = true
+	base := newScan(1, []Finding{finding("moved", 2), finding("deleted", 5), finding("", 7)})
+	scan := newScan(2, []Finding{finding("moved", 12), finding("added", 3), finding("added", 3), finding("", 7), suppressed})
+
+	diff, err := NewScanDiff(scan, base)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), diff.ScanID)
+	require.Equal(t, int64(1), *diff.BaseScanID)
+	require.Equal(t, []Finding{finding("added", 3)}, diff.New)
+	require.Equal(t, []Finding{finding("deleted", 5)}, diff.Fixed)
+	// the moved finding is reported where the scan found it
+	require.Equal(t, []Finding{finding("moved", 12), finding("", 7)}, diff.Unchanged)
+}
+
+func TestNewScanDiffWithoutBase(t *testing.T) {
+	scan := &Scan{ID: 2, Findings: []byte(`[{"ruleId":"G101","fingerprint":"added"},{"ruleId":"G101","fingerprint":"legacy","baselined":true}]`)}
+
+	diff, err := NewScanDiff(scan, nil)
+	require.NoError(t, err)
+	require.Nil(t, diff.BaseScanID)
+	require.Len(t, diff.New, 1)
+	require.Empty(t, diff.Fixed)
+	// baselined findings are never new
+	require.Len(t, diff.Unchanged, 1)
+}
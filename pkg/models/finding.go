@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	FindingStateOpen          = "open"
+	FindingStateAcknowledged  = "acknowledged"
+	FindingStateFalsePositive = "false_positive"
+	FindingStateWontFix       = "wont_fix"
+	FindingStateResolved      = "resolved"
+)
+
+// FindingRecord tracks a finding of a repository across its scans by fingerprint, so
+// that the triage decision made on it applies to the scans that report it again.
+type FindingRecord struct {
+	ID           int64      `json:"id"`
+	RepositoryID int64      `json:"repository_id"`
+	Fingerprint  string     `json:"fingerprint"`
+	RuleID       string     `json:"rule_id"`
+	Path         string     `json:"path"`
+	Severity     string     `json:"severity"`
+	Description  string     `json:"description"`
+	State        string     `json:"state"`
+	Comment      string     `json:"comment"`
+	Actor        string     `json:"actor"` // who made the last triage decision
+	FirstScanID  int64      `json:"first_scan_id"`
+	LastScanID   int64      `json:"last_scan_id"`
+	TriagedAt    *time.Time `json:"triaged_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type FindingFilter struct {
+	RepositoryID *int64
+	RuleID       *string
+	Severity     *string
+	PathPrefix   *string // e.g. config/ for the findings under the config directory of a repository
+	State        *string
+}
+
+func (FindingRecord) TableName() string {
+	return "findings"
+}
+
+func IsValidFindingState(state string) bool {
+	switch state {
+	case FindingStateOpen, FindingStateAcknowledged, FindingStateFalsePositive,
+		FindingStateWontFix, FindingStateResolved:
+		return true
+	}
+
+	return false
+}
+
+// NewFindingRecord starts tracking a finding first reported by the scan.
+func NewFindingRecord(repositoryID int64, scanID int64, finding *Finding) *FindingRecord {
+	return &FindingRecord{
+		RepositoryID: repositoryID,
+		Fingerprint:  finding.Fingerprint,
+		RuleID:       finding.RuleID,
+		Path:         finding.Location.Path,
+		Severity:     finding.Metadata.Severity,
+		Description:  finding.Metadata.Description,
+		State:        FindingStateOpen,
+		FirstScanID:  scanID,
+		LastScanID:   scanID,
+	}
+}
@@ -1,10 +1,26 @@
 package models
 
+import "time"
+
 type Finding struct {
 	Type     string   `json:"type"`
 	RuleID   string   `json:"ruleId"`
 	Location Location `json:"location"`
 	Metadata Metadata `json:"metadata"`
+	Commit   *Commit  `json:"commit,omitempty"`
+
+	// set by a scan:ignore comment, the finding is kept for audit
+	Suppressed    bool   `json:"suppressed,omitempty"`
+	Justification string `json:"justification,omitempty"`
+
+	// accepted by the baseline of the repository, it does not count as failing
+	Baselined bool `json:"baselined,omitempty"`
+
+	// identifies the finding across scans of the repository, see FindingRecord
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// the tracked finding and its triage state, set once the scan result is stored
+	ID    int64  `json:"id,omitempty"`
+	State string `json:"state,omitempty"`
 }
 
 type Location struct {
@@ -12,15 +28,34 @@ type Location struct {
 	Position Position `json:"positions"`
 }
 
+// Position is the span of the matched secret. Lines and columns are 1-based,
+// columns count characters and End.Column points just past the last one.
+// Findings produced before columns existed only carry Begin.Line.
 type Position struct {
 	Begin Begin `json:"begin"`
+	End   End   `json:"end"`
 }
 
 type Begin struct {
-	Line int `json:"line"`
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type End struct {
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+// Commit is the commit that introduced a finding, only known when the history is scanned.
+type Commit struct {
+	SHA    string    `json:"sha"`
+	Author string    `json:"author"`
+	Email  string    `json:"email"`
+	Date   time.Time `json:"date"`
 }
 
 type Metadata struct {
-	Description string `json:"description"`
-	Severity    string `json:"severity"`
+	Description string  `json:"description"`
+	Severity    string  `json:"severity"`
+	Confidence  float64 `json:"confidence,omitempty"`
 }
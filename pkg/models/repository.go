@@ -2,6 +2,8 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -19,16 +21,34 @@ type RepositoryFilter struct {
 	RepositoryID *int64
 }
 
+// NewRepository parses the https URL of a repository on any git host. The name is
+// the last path segment and the owner everything before it, so GitLab subgroups
+// like https://gitlab.com/group/subgroup/name are supported. The URL is stored
+// without its .git suffix so that both forms point at the same repository.
 func NewRepository(repositoryURL string) (*Repository, error) {
-	splittedURL := strings.Split(repositoryURL, "/")
-	if len(splittedURL) != 5 {
+	parsedURL, err := url.Parse(strings.TrimSpace(repositoryURL))
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Host == "" ||
+		parsedURL.User != nil || parsedURL.RawQuery != "" || parsedURL.Fragment != "" {
 		return nil, errors.New("invalid repository")
 	}
 
-	owner := splittedURL[3]
-	name := splittedURL[4]
+	// GitLab serves the pages of a project under /-/, e.g. /group/name/-/tree/main
+	path, _, _ := strings.Cut(parsedURL.Path, "/-/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) < 2 {
+		return nil, errors.New("invalid repository")
+	}
+	segments[len(segments)-1] = strings.TrimSuffix(segments[len(segments)-1], ".git")
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." {
+			return nil, errors.New("invalid repository")
+		}
+	}
+
+	owner := strings.Join(segments[:len(segments)-1], "/")
+	name := segments[len(segments)-1]
 	return &Repository{
-		RepositoryURL: repositoryURL,
+		RepositoryURL: fmt.Sprintf("https://%s/%s/%s", strings.ToLower(parsedURL.Host), owner, name),
 		Owner:         owner,
 		Name:          name,
 	}, nil
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/datatypes"
@@ -12,40 +14,136 @@ const (
 	ScanStatusInProgress = "In Progress"
 	ScanStatusSuccess    = "Success"
 	ScanStatusFailure    = "Failure"
+	ScanStatusCancelled  = "Cancelled"
+)
+
+// scanTransitions lists the statuses a scan may move to from each status. A scan goes
+// Pending, Queued, In Progress then Success or Failure; it may be cancelled until then.
+// A queued scan fails when it goes stale before a worker picks it up, and may finish
+// right away since result messages are not ordered, the In Progress one may come last.
+var scanTransitions = map[string][]string{
+	ScanStatusPending:    {ScanStatusQueued, ScanStatusCancelled},
+	ScanStatusQueued:     {ScanStatusInProgress, ScanStatusSuccess, ScanStatusFailure, ScanStatusCancelled},
+	ScanStatusInProgress: {ScanStatusSuccess, ScanStatusFailure, ScanStatusCancelled},
+}
+
+// IsValidScanStatus tells whether a status is one of the scan statuses.
+func IsValidScanStatus(status string) bool {
+	switch status {
+	case ScanStatusPending, ScanStatusQueued, ScanStatusInProgress,
+		ScanStatusSuccess, ScanStatusFailure, ScanStatusCancelled:
+		return true
+	}
+
+	return false
+}
+
+// IsFinishedScanStatus tells whether a scan in the status moves no more.
+func IsFinishedScanStatus(status string) bool {
+	return IsValidScanStatus(status) && len(scanTransitions[status]) == 0
+}
+
+// CanTransitionScan tells whether a scan may move from a status to another, finished
+// scans never move again.
+func CanTransitionScan(from string, to string) bool {
+	for _, status := range scanTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PreviousScanStatuses returns the statuses a scan may move to the status from.
+func PreviousScanStatuses(to string) []string {
+	statuses := []string{}
+	for _, from := range []string{ScanStatusPending, ScanStatusQueued, ScanStatusInProgress} {
+		if CanTransitionScan(from, to) {
+			statuses = append(statuses, from)
+		}
+	}
+
+	return statuses
+}
+
+// error codes telling why a scan failed
+const (
+	ScanErrorRepoNotFound      = "repo_not_found" // the repository does not exist or the credential has no access to it
+	ScanErrorRefNotFound       = "ref_not_found"
+	ScanErrorInvalidCredential = "invalid_credential"
+	ScanErrorRateLimited       = "rate_limited"
+	ScanErrorArchiveTooLarge   = "archive_too_large"
+	ScanErrorTimeout           = "timeout"
+	ScanErrorStale             = "stale" // the scan made no progress, the worker running it likely died
+	ScanErrorUnknown           = "unknown"
 )
 
 type Scan struct {
-	ID             int64          `json:"id"`
-	RepositoryID   int64          `json:"repository_id"`
-	RepositoryName string         `json:"repository_name"`
-	RepositoryURL  string         `json:"repository_url"`
-	Findings       datatypes.JSON `json:"findings"`
-	Status         string         `json:"status"`
-	QueuedAt       *time.Time     `json:"queued_at"`
-	ScanningAt     *time.Time     `json:"scanning_at"`
-	FinishedAt     *time.Time     `json:"finished_at"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
+	ID              int64          `json:"id"`
+	RepositoryID    int64          `json:"repository_id"`
+	ParentScanID    *int64         `json:"parent_scan_id"` // the scan this one retries
+	RepositoryName  string         `json:"repository_name"`
+	RepositoryURL   string         `json:"repository_url"`
+	Ref             string         `json:"ref"`
+	CommitSHA       string         `json:"commit_sha"`
+	History         bool           `json:"history"`
+	Findings        datatypes.JSON `json:"findings"`
+	Stats           datatypes.JSON `json:"stats"`
+	FailingFindings int            `json:"failing_findings"` // findings neither suppressed nor baselined
+	Status          string         `json:"status"`
+	ErrorCode       string         `json:"error_code"` // why the scan failed, empty unless it did
+	ErrorMessage    string         `json:"error_message"`
+	QueuedAt        *time.Time     `json:"queued_at"`
+	ScanningAt      *time.Time     `json:"scanning_at"`
+	FinishedAt      *time.Time     `json:"finished_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
 }
 
 type ScanRequestMessage struct {
-	ScanID     int64  `json:"scan_id"`
-	Owner      string `json:"owner"`
-	Repository string `json:"repository"`
+	ScanID        int64  `json:"scan_id"`
+	Owner         string `json:"owner"`
+	Repository    string `json:"repository"`
+	RepositoryURL string `json:"repository_url,omitempty"`
+	Ref           string `json:"ref,omitempty"` // branch, tag or commit SHA, the default branch if empty
+	History       bool   `json:"history,omitempty"`
+
+	Credential *SealedCredential `json:"credential,omitempty"` // set when the repository is private
+}
+
+// ScanCancelMessage asks the execution service to stop the task scanning a scan.
+type ScanCancelMessage struct {
+	ScanID int64 `json:"scan_id"`
 }
 
 type ScanResultMessage struct {
-	ScanID     int64      `json:"scan_id"`
-	ScanStatus string     `json:"scan_status"`
-	Findings   []byte     `json:"findings"`
-	ScanningAt *time.Time `json:"scanning_at,omitempty"`
-	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ScanID       int64      `json:"scan_id"`
+	ScanStatus   string     `json:"scan_status"`
+	ErrorCode    string     `json:"error_code,omitempty"` // set on failure
+	ErrorMessage string     `json:"error_message,omitempty"`
+	Findings     []byte     `json:"findings"`
+	CommitSHA    string     `json:"commit_sha,omitempty"`
+	Stats        *ScanStats `json:"stats,omitempty"`
+	ScanningAt   *time.Time `json:"scanning_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// ScanStats counts the files a scan read and the ones it skipped, by reason.
+type ScanStats struct {
+	ScannedFiles   int `json:"scanned_files"`
+	IgnoredPaths   int `json:"ignored_paths"` // vendored directories and .scanignore matches, a directory counts once
+	BinaryFiles    int `json:"binary_files"`
+	OversizedFiles int `json:"oversized_files"`
 }
 
 type ScanFilter struct {
 	RepositoryID   *int64
 	RepositoryName *string
 	Status         *string
+	Ref            *string
+	History        *bool
+	BeforeID       *int64 // only the scans created before this one
 }
 
 func NewScan(repository *Repository) (*Scan, error) {
@@ -56,3 +154,115 @@ func NewScan(repository *Repository) (*Scan, error) {
 		Status:         ScanStatusPending,
 	}, nil
 }
+
+// ParseFindings decodes the findings stored on the scan, a scan without findings yields an empty list.
+// IsStaleFailure tells whether the stale checker failed the scan. The worker may still be
+// running it, a slow scan is not told apart from a lost one.
+func (s *Scan) IsStaleFailure() bool {
+	return s.Status == ScanStatusFailure && s.ErrorCode == ScanErrorStale
+}
+
+// CanMoveTo tells whether the scan may move to a status. Besides the transitions between
+// statuses, the outcome the worker reports replaces a stale failure.
+func (s *Scan) CanMoveTo(to string) bool {
+	if s.IsStaleFailure() {
+		return to == ScanStatusSuccess || to == ScanStatusFailure
+	}
+
+	return CanTransitionScan(s.Status, to)
+}
+
+func (s *Scan) ParseFindings() ([]Finding, error) {
+	findings := []Finding{}
+	if len(s.Findings) == 0 {
+		return findings, nil
+	}
+
+	if err := json.Unmarshal(s.Findings, &findings); err != nil {
+		return nil, err
+	}
+	if findings == nil {
+		findings = []Finding{}
+	}
+
+	return findings, nil
+}
+
+// ScanSummary counts the findings of a scan, the counts by severity and rule leave
+// the suppressed findings out.
+type ScanSummary struct {
+	Findings           int            `json:"findings"`
+	FailingFindings    int            `json:"failing_findings"`
+	SuppressedFindings int            `json:"suppressed_findings"`
+	BaselinedFindings  int            `json:"baselined_findings"`
+	BySeverity         map[string]int `json:"by_severity"`
+	ByRule             map[string]int `json:"by_rule"`
+	ScannedFiles       *int           `json:"scanned_files"` // nil when the history is scanned, files are not counted
+}
+
+// ScanDetail is a scan along with the summary of its findings.
+type ScanDetail struct {
+	*Scan
+	Summary *ScanSummary `json:"summary"`
+}
+
+func NewScanSummary(scan *Scan) (*ScanSummary, error) {
+	findings, err := scan.ParseFindings()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ScanSummary{
+		Findings:        len(findings),
+		FailingFindings: CountFailingFindings(findings),
+		BySeverity:      map[string]int{},
+		ByRule:          map[string]int{},
+	}
+	for _, finding := range findings {
+		if finding.Suppressed {
+			summary.SuppressedFindings++
+			continue
+		}
+		if finding.Baselined {
+			summary.BaselinedFindings++
+		}
+		summary.BySeverity[finding.Metadata.Severity]++
+		summary.ByRule[finding.RuleID]++
+	}
+
+	if len(scan.Stats) > 0 {
+		stats := &ScanStats{}
+		if err := json.Unmarshal(scan.Stats, stats); err != nil {
+			return nil, err
+		}
+		summary.ScannedFiles = &stats.ScannedFiles
+	}
+
+	return summary, nil
+}
+
+// Select keeps the given top level fields of the detail, all of them without fields.
+func (d *ScanDetail) Select(fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	allFields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &allFields); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return allFields, nil
+	}
+
+	selected := map[string]json.RawMessage{}
+	for _, field := range fields {
+		value, found := allFields[field]
+		if !found {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+		selected[field] = value
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewScanSummary(t *testing.T) {
+	scan := &Scan{
+		Findings: []byte(`[` +
+			`{"ruleId":"G101","metadata":{"severity":"HIGH"}},` +
+			`{"ruleId":"G101","metadata":{"severity":"HIGH"},"baselined":true},` +
+			`{"ruleId":"G108","metadata":{"severity":"MEDIUM"}},` +
+			`{"ruleId":"G101","metadata":{"severity":"HIGH"},"suppressed":true}]`),
+		Stats: []byte(`{"scanned_files":12,"ignored_paths":1,"binary_files":2,"oversized_files":0}`),
+	}
+
+	summary, err := NewScanSummary(scan)
+	require.NoError(t, err)
+	scannedFiles := 12
+	require.Equal(t, &ScanSummary{
+		Findings:           4,
+		FailingFindings:    2,
+		SuppressedFindings: 1,
+		BaselinedFindings:  1,
+		BySeverity:         map[string]int{"HIGH": 2, "MEDIUM": 1},
+		ByRule:             map[string]int{"G101": 2, "G108": 1},
+		ScannedFiles:       &scannedFiles,
+	}, summary)
+
+	summary, err = NewScanSummary(&Scan{})
+	require.NoError(t, err)
+	require.Zero(t, summary.Findings)
+	require.Nil(t, summary.ScannedFiles)
+}
+
+func TestScanDetailSelect(t *testing.T) {
+	detail := &ScanDetail{
+		Scan:    &Scan{ID: 1, Status: ScanStatusSuccess, Findings: []byte(`[{"ruleId":"G101"}]`)},
+		Summary: &ScanSummary{Findings: 1},
+	}
+
+	fields, err := detail.Select(nil)
+	require.NoError(t, err)
+	require.Contains(t, fields, "findings")
+	require.Contains(t, fields, "summary")
+
+	fields, err = detail.Select([]string{"id", "status", "summary"})
+	require.NoError(t, err)
+	require.Len(t, fields, 3)
+	require.Equal(t, json.RawMessage(`"Success"`), fields["status"])
+
+	_, err = detail.Select([]string{"id", "secrets"})
+	require.Error(t, err)
+}
+
+func TestIsValidScanStatus(t *testing.T) {
+	require.True(t, IsValidScanStatus(ScanStatusInProgress))
+	require.True(t, IsValidScanStatus(ScanStatusCancelled))
+	require.False(t, IsValidScanStatus("Done"))
+	require.False(t, IsValidScanStatus(""))
+}
+
+func TestIsFinishedScanStatus(t *testing.T) {
+	require.True(t, IsFinishedScanStatus(ScanStatusSuccess))
+	require.True(t, IsFinishedScanStatus(ScanStatusFailure))
+	require.True(t, IsFinishedScanStatus(ScanStatusCancelled))
+	require.False(t, IsFinishedScanStatus(ScanStatusQueued))
+	require.False(t, IsFinishedScanStatus("Done"))
+}
+
+func TestCanTransitionScan(t *testing.T) {
+	require.True(t, CanTransitionScan(ScanStatusPending, ScanStatusQueued))
+	require.True(t, CanTransitionScan(ScanStatusQueued, ScanStatusInProgress))
+	require.True(t, CanTransitionScan(ScanStatusQueued, ScanStatusCancelled))
+	require.True(t, CanTransitionScan(ScanStatusInProgress, ScanStatusSuccess))
+	require.True(t, CanTransitionScan(ScanStatusInProgress, ScanStatusFailure))
+	// the In Progress message may come after the result
+	require.True(t, CanTransitionScan(ScanStatusQueued, ScanStatusSuccess))
+
+	// redelivered messages must not move a scan back
+	require.False(t, CanTransitionScan(ScanStatusSuccess, ScanStatusInProgress))
+	require.False(t, CanTransitionScan(ScanStatusInProgress, ScanStatusInProgress))
+	require.False(t, CanTransitionScan(ScanStatusCancelled, ScanStatusSuccess))
+	require.False(t, CanTransitionScan(ScanStatusPending, ScanStatusSuccess))
+	require.False(t, CanTransitionScan(ScanStatusInProgress, "Done"))
+}
+
+func TestScanCanMoveTo(t *testing.T) {
+	staleScan := &Scan{Status: ScanStatusFailure, ErrorCode: ScanErrorStale}
+	require.True(t, staleScan.IsStaleFailure())
+	require.True(t, staleScan.CanMoveTo(ScanStatusSuccess))
+	require.True(t, staleScan.CanMoveTo(ScanStatusFailure))
+	require.False(t, staleScan.CanMoveTo(ScanStatusInProgress))
+
+	failedScan := &Scan{Status: ScanStatusFailure, ErrorCode: ScanErrorTimeout}
+	require.False(t, failedScan.IsStaleFailure())
+	require.False(t, failedScan.CanMoveTo(ScanStatusSuccess))
+
+	require.True(t, (&Scan{Status: ScanStatusInProgress}).CanMoveTo(ScanStatusSuccess))
+}
+
+func TestPreviousScanStatuses(t *testing.T) {
+	require.Equal(t, []string{ScanStatusPending, ScanStatusQueued, ScanStatusInProgress}, PreviousScanStatuses(ScanStatusCancelled))
+	require.Equal(t, []string{ScanStatusQueued, ScanStatusInProgress}, PreviousScanStatuses(ScanStatusFailure))
+	require.Equal(t, []string{ScanStatusQueued, ScanStatusInProgress}, PreviousScanStatuses(ScanStatusSuccess))
+	require.Empty(t, PreviousScanStatuses(ScanStatusPending))
+}
@@ -0,0 +1,261 @@
+package sarif
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+// Format of the Static Analysis Results Interchange Format (SARIF) 2.1.0,
+// see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName           = "scan"
+	toolInformationURI = "https://github.com/vumanhcuongit/scan"
+
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+
+	SuppressionKindInSource = "inSource"
+	SuppressionKindExternal = "external" // accepted by the baseline of the repository
+
+	baselineJustification = "accepted by the baseline of the repository"
+)
+
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool                     Tool                    `json:"tool"`
+	Results                  []Result                `json:"results"`
+	VersionControlProvenance []VersionControlDetails `json:"versionControlProvenance,omitempty"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string                `json:"name"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules"`
+}
+
+type ReportingDescriptor struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     *Message               `json:"shortDescription,omitempty"`
+	DefaultConfiguration *Configuration         `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleID       string                 `json:"ruleId"`
+	RuleIndex    int                    `json:"ruleIndex"`
+	Level        string                 `json:"level"`
+	Message      Message                `json:"message"`
+	Locations    []Location             `json:"locations"`
+	Suppressions []Suppression          `json:"suppressions,omitempty"`
+	Properties   map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Suppression tells consumers the result was dismissed, by a comment in the source for scan:ignore
+// or by the baseline of the repository.
+type Suppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type VersionControlDetails struct {
+	RepositoryURI string `json:"repositoryUri"`
+	RevisionID    string `json:"revisionId,omitempty"` // commit the findings belong to
+	Branch        string `json:"branch,omitempty"`     // ref the scan was triggered for, a tag ends up here too
+}
+
+// NewLog converts the findings of a scan into a SARIF log with a single run.
+// Every rule referenced by a finding is described once in tool.driver.rules.
+func NewLog(scan *models.Scan, findings []models.Finding) *Log {
+	run := Run{
+		Tool: Tool{
+			Driver: Driver{
+				Name:           toolName,
+				InformationURI: toolInformationURI,
+				Rules:          []ReportingDescriptor{},
+			},
+		},
+		Results: []Result{},
+	}
+	if scan != nil && scan.RepositoryURL != "" {
+		details := VersionControlDetails{RepositoryURI: scan.RepositoryURL, RevisionID: scan.CommitSHA}
+		if scan.Ref != scan.CommitSHA {
+			details.Branch = scan.Ref
+		}
+		run.VersionControlProvenance = []VersionControlDetails{details}
+	}
+
+	ruleIndexes := map[string]int{}
+	for _, finding := range findings {
+		if _, ok := ruleIndexes[finding.RuleID]; ok {
+			continue
+		}
+		ruleIndexes[finding.RuleID] = 0
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newReportingDescriptor(&finding))
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+	for i, rule := range run.Tool.Driver.Rules {
+		ruleIndexes[rule.ID] = i
+	}
+
+	for _, finding := range findings {
+		run.Results = append(run.Results, newResult(&finding, ruleIndexes[finding.RuleID]))
+	}
+
+	return &Log{
+		Schema:  Schema,
+		Version: Version,
+		Runs:    []Run{run},
+	}
+}
+
+func newReportingDescriptor(finding *models.Finding) ReportingDescriptor {
+	rule := ReportingDescriptor{
+		ID: finding.RuleID,
+		DefaultConfiguration: &Configuration{
+			Level: Level(finding.Metadata.Severity),
+		},
+		Properties: map[string]interface{}{
+			"tags": []string{"security", "secret"},
+		},
+	}
+	if finding.Metadata.Description != "" {
+		rule.ShortDescription = &Message{Text: finding.Metadata.Description}
+	}
+	if score, ok := securitySeverity(finding.Metadata.Severity); ok {
+		rule.Properties["security-severity"] = score
+	}
+
+	return rule
+}
+
+func newResult(finding *models.Finding, ruleIndex int) Result {
+	message := finding.Metadata.Description
+	if message == "" {
+		message = finding.RuleID
+	}
+
+	result := Result{
+		RuleID:    finding.RuleID,
+		RuleIndex: ruleIndex,
+		Level:     Level(finding.Metadata.Severity),
+		Message:   Message{Text: message},
+		Locations: []Location{
+			{
+				PhysicalLocation: PhysicalLocation{
+					ArtifactLocation: ArtifactLocation{URI: finding.Location.Path},
+					Region:           newRegion(&finding.Location.Position),
+				},
+			},
+		},
+	}
+	if finding.Suppressed {
+		result.Suppressions = []Suppression{{Kind: SuppressionKindInSource, Justification: finding.Justification}}
+	} else if finding.Baselined {
+		result.Suppressions = []Suppression{{Kind: SuppressionKindExternal, Justification: baselineJustification}}
+	}
+	properties := map[string]interface{}{}
+	if finding.Metadata.Confidence > 0 {
+		properties["confidence"] = finding.Metadata.Confidence
+	}
+	if finding.Commit != nil {
+		properties["commitSha"] = finding.Commit.SHA
+		properties["commitAuthor"] = finding.Commit.Author
+		properties["commitDate"] = finding.Commit.Date
+	}
+	if len(properties) > 0 {
+		result.Properties = properties
+	}
+
+	return result
+}
+
+func newRegion(position *models.Position) *Region {
+	if position.Begin.Line <= 0 {
+		return nil
+	}
+
+	region := &Region{
+		StartLine:   position.Begin.Line,
+		StartColumn: position.Begin.Column,
+	}
+	if position.End.Line >= position.Begin.Line {
+		region.EndLine = position.End.Line
+		if position.End.Column > 0 {
+			region.EndColumn = position.End.Column
+		}
+	}
+
+	return region
+}
+
+// Level maps a finding's severity to a SARIF result level.
+func Level(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL", "HIGH":
+		return LevelError
+	case "MEDIUM":
+		return LevelWarning
+	default:
+		return LevelNote
+	}
+}
+
+// securitySeverity is the numeric score code scanning dashboards use to rank security results.
+func securitySeverity(severity string) (string, bool) {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return "9.5", true
+	case "HIGH":
+		return "8.0", true
+	case "MEDIUM":
+		return "5.5", true
+	case "LOW":
+		return "2.0", true
+	}
+	return "", false
+}
@@ -0,0 +1,146 @@
+package sarif
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vumanhcuongit/scan/pkg/models"
+)
+
+func newFinding(ruleID, severity, path string, position models.Position) models.Finding {
+	return models.Finding{
+		Type:   "sast",
+		RuleID: ruleID,
+		Location: models.Location{
+			Path:     path,
+			Position: position,
+		},
+		Metadata: models.Metadata{
+			Description: "description of " + ruleID,
+			Severity:    severity,
+		},
+	}
+}
+
+func TestNewLog(t *testing.T) {
+	scan := &models.Scan{
+		ID:            1,
+		RepositoryURL: "https://github.com/vumanhcuongit/scan",
+		Ref:           "main",
+		CommitSHA:     "2d4a8f3c9e1b7a6d5c4f3e2a1b0c9d8e7f6a5b4c",
+	}
+	findings := []models.Finding{
+		newFinding("G104", "HIGH", "cmd/main.go", models.Position{
+			Begin: models.Begin{Line: 3, Column: 10},
+			End:   models.End{Line: 3, Column: 50},
+		}),
+		newFinding("G101", "LOW", "README.md", models.Position{
+			Begin: models.Begin{Line: 7},
+		}),
+		newFinding("G104", "HIGH", "pkg/app.go", models.Position{
+			Begin: models.Begin{Line: 1, Column: 1},
+			End:   models.End{Line: 2, Column: 5},
+		}),
+	}
+
+	log := NewLog(scan, findings)
+	require.Equal(t, Version, log.Version)
+	require.Equal(t, Schema, log.Schema)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	require.Equal(t, []VersionControlDetails{{
+		RepositoryURI: scan.RepositoryURL,
+		RevisionID:    scan.CommitSHA,
+		Branch:        "main",
+	}}, run.VersionControlProvenance)
+
+	rules := run.Tool.Driver.Rules
+	require.Len(t, rules, 2)
+	require.Equal(t, "G101", rules[0].ID)
+	require.Equal(t, LevelNote, rules[0].DefaultConfiguration.Level)
+	require.Equal(t, "G104", rules[1].ID)
+	require.Equal(t, LevelError, rules[1].DefaultConfiguration.Level)
+	require.Equal(t, "description of G104", rules[1].ShortDescription.Text)
+	require.Equal(t, "8.0", rules[1].Properties["security-severity"])
+
+	require.Len(t, run.Results, 3)
+	first := run.Results[0]
+	require.Equal(t, "G104", first.RuleID)
+	require.Equal(t, 1, first.RuleIndex)
+	require.Equal(t, LevelError, first.Level)
+	require.Equal(t, "cmd/main.go", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, &Region{StartLine: 3, StartColumn: 10, EndLine: 3, EndColumn: 50}, first.Locations[0].PhysicalLocation.Region)
+
+	second := run.Results[1]
+	require.Equal(t, 0, second.RuleIndex)
+	require.Equal(t, &Region{StartLine: 7}, second.Locations[0].PhysicalLocation.Region)
+
+	third := run.Results[2]
+	require.Equal(t, &Region{StartLine: 1, StartColumn: 1, EndLine: 2, EndColumn: 5}, third.Locations[0].PhysicalLocation.Region)
+	require.Nil(t, third.Properties)
+}
+
+func TestNewLogOfCommitRef(t *testing.T) {
+	commitSHA := "2d4a8f3c9e1b7a6d5c4f3e2a1b0c9d8e7f6a5b4c"
+	scan := &models.Scan{RepositoryURL: "https://github.com/vumanhcuongit/scan", Ref: commitSHA, CommitSHA: commitSHA}
+
+	log := NewLog(scan, nil)
+	require.Equal(t, []VersionControlDetails{{RepositoryURI: scan.RepositoryURL, RevisionID: commitSHA}},
+		log.Runs[0].VersionControlProvenance)
+}
+
+func TestNewLogWithCommit(t *testing.T) {
+	finding := newFinding("G101", "HIGH", "config.py", models.Position{Begin: models.Begin{Line: 2}})
+	date := time.Date(2022, 10, 1, 9, 30, 0, 0, time.UTC)
+	finding.Commit = &models.Commit{SHA: "2d4a8f3c9e1b7a6d5c4f3e2a1b0c9d8e7f6a5b4c", Author: "Alice", Date: date}
+
+	log := NewLog(&models.Scan{}, []models.Finding{finding})
+	properties := log.Runs[0].Results[0].Properties
+	require.Equal(t, finding.Commit.SHA, properties["commitSha"])
+	require.Equal(t, "Alice", properties["commitAuthor"])
+	require.Equal(t, date, properties["commitDate"])
+}
+
+func TestNewLogWithSuppressedFinding(t *testing.T) {
+	finding := newFinding("G101", "HIGH", "config_test.py", models.Position{Begin: models.Begin{Line: 2}})
+	finding.Suppressed = true
+	finding.Justification = "test fixture"
+
+	log := NewLog(&models.Scan{}, []models.Finding{finding})
+	require.Equal(t, []Suppression{{Kind: SuppressionKindInSource, Justification: "test fixture"}}, log.Runs[0].Results[0].Suppressions)
+}
+
+func TestNewLogWithBaselinedFinding(t *testing.T) {
+	finding := newFinding("G101", "HIGH", "config.py", models.Position{Begin: models.Begin{Line: 2}})
+	finding.Baselined = true
+
+	log := NewLog(&models.Scan{}, []models.Finding{finding})
+	require.Equal(t, SuppressionKindExternal, log.Runs[0].Results[0].Suppressions[0].Kind)
+}
+
+func TestNewLogWithoutFindings(t *testing.T) {
+	log := NewLog(&models.Scan{}, nil)
+	data, err := json.Marshal(log)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, "2.1.0", decoded["version"])
+	require.Equal(t, Schema, decoded["$schema"])
+
+	run := decoded["runs"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, []interface{}{}, run["results"])
+	require.Equal(t, []interface{}{}, run["tool"].(map[string]interface{})["driver"].(map[string]interface{})["rules"])
+	require.NotContains(t, run, "versionControlProvenance")
+}
+
+func TestLevel(t *testing.T) {
+	require.Equal(t, LevelError, Level("CRITICAL"))
+	require.Equal(t, LevelError, Level("high"))
+	require.Equal(t, LevelWarning, Level("MEDIUM"))
+	require.Equal(t, LevelNote, Level("LOW"))
+	require.Equal(t, LevelNote, Level(""))
+}
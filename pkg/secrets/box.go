@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// KeySize is the size of the encryption key, AES-256.
+const KeySize = 32
+
+// Box encrypts secrets at rest with AES-256-GCM. A sealed secret is the base64
+// encoding of a random nonce followed by the ciphertext, so sealing the same
+// secret twice yields different values.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox creates a Box from the base64 encoding of a 32 bytes key.
+func NewBox(key string) (*Box, error) {
+	rawKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.New("encryption key is not valid base64")
+	}
+	if len(rawKey) != KeySize {
+		return nil, errors.New("encryption key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts the plaintext.
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value returned by Seal, it fails if the value was tampered with
+// or sealed with another key.
+func (b *Box) Open(sealed string) (string, error) {
+	rawSealed, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", errors.New("sealed secret is not valid base64")
+	}
+	if len(rawSealed) < b.aead.NonceSize() {
+		return "", errors.New("sealed secret is too short")
+	}
+
+	nonce, ciphertext := rawSealed[:b.aead.NonceSize()], rawSealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt sealed secret")
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,55 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), KeySize)))
+}
+
+func TestNewBox(t *testing.T) {
+	_, err := NewBox(newTestKey('k'))
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		_, err = NewBox(key)
+		require.Error(t, err, key)
+	}
+}
+
+func TestSealAndOpen(t *testing.T) {
+	box, err := NewBox(newTestKey('k'))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal("ghp_secret")
+	require.NoError(t, err)
+	require.NotContains(t, sealed, "ghp_secret")
+
+	resealed, err := box.Seal("ghp_secret")
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, resealed)
+
+	plaintext, err := box.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, "ghp_secret", plaintext)
+
+	otherBox, err := NewBox(newTestKey('o'))
+	require.NoError(t, err)
+	_, err = otherBox.Open(sealed)
+	require.Error(t, err)
+
+	rawSealed, _ := base64.StdEncoding.DecodeString(sealed)
+	rawSealed[len(rawSealed)-1] ^= 1
+	_, err = box.Open(base64.StdEncoding.EncodeToString(rawSealed))
+	require.Error(t, err)
+
+	for _, value := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("tiny"))} {
+		_, err = box.Open(value)
+		require.Error(t, err, value)
+	}
+}